@@ -0,0 +1,110 @@
+package applycmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
+	"github.com/joerdav/flagship/cmd/flagship/docfile"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command makes the features and throttles of the record match a JSON or YAML file.
+type Command struct {
+	Store dynamostore.DynamoStore
+	In    io.Reader
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("apply")
+	file := f.StringP("file", "f", "", "JSON or YAML document to apply, - for stdin")
+	yes := f.BoolP("yes", "y", false, "Apply without asking for confirmation")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if *file == "" {
+		c.Help()
+		return errors.New("No --file provided.")
+	}
+	doc, err := docfile.Read(*file, c.In)
+	if err != nil {
+		return fmt.Errorf("Error reading %s: %s", *file, err.Error())
+	}
+	if problems := flagship.ValidateDocument(doc); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(c.Out, p.Error())
+		}
+		return fmt.Errorf("%s is invalid, %d problems found", *file, len(problems))
+	}
+	ctx := context.Background()
+	p, err := c.Store.PlanApply(ctx, doc)
+	if err != nil {
+		return fmt.Errorf("Error planning changes: %s", err.Error())
+	}
+	if len(p.Changes) == 0 {
+		fmt.Fprintln(c.Out, "No changes.")
+		return nil
+	}
+	var adds, changes, removals int
+	fmt.Fprintf(c.Out, "Plan for %s:\n", c.Store.Record)
+	for _, ch := range p.Changes {
+		switch {
+		case ch.Old == nil:
+			adds++
+			fmt.Fprintf(c.Out, "	+ %s %s: %s\n", ch.Type, ch.Name, display(ch.New))
+		case ch.New == nil:
+			removals++
+			fmt.Fprintf(c.Out, "	- %s %s: %s\n", ch.Type, ch.Name, display(ch.Old))
+		default:
+			changes++
+			fmt.Fprintf(c.Out, "	~ %s %s: %s -> %s\n", ch.Type, ch.Name, display(ch.Old), display(ch.New))
+		}
+	}
+	fmt.Fprintf(c.Out, "%d to add, %d to change, %d to remove.\n", adds, changes, removals)
+	if err := dependents.WarnChanges(ctx, c.Out, c.Store, p.Changes); err != nil {
+		return err
+	}
+	if !*yes {
+		fmt.Fprint(c.Out, "Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(c.In).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("Apply cancelled.")
+		}
+	}
+	store := c.Store
+	if store.Reason == "" {
+		store.Reason = "apply " + *file
+	}
+	if err := store.ApplyPromotion(ctx, p); err != nil {
+		return fmt.Errorf("Error applying changes: %s", err.Error())
+	}
+	fmt.Fprintf(c.Out, "Applied %d changes.\n", len(p.Changes))
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship apply --file <path> [--yes]
+	Makes the features and throttles of the record match a JSON or YAML file, such as one written by flagship export.
+	The file is validated, then a plan of adds, changes and removals is shown and confirmation is asked for.
+	Features and throttles that are not in the file are removed. The version, kill switch and freeze are not applied.
+	If the record is modified after the plan is made, nothing is applied.
+	Features that other features or throttles depend on are warned about.
+	Use --yes to skip confirmation.`)
+}
+
+func display(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
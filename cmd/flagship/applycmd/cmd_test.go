@@ -0,0 +1,189 @@
+package applycmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+// editingReader simulates a concurrent edit to the record while the user is being asked for confirmation.
+type editingReader struct {
+	edit func()
+	r    io.Reader
+}
+
+func (e *editingReader) Read(p []byte) (int, error) {
+	if e.edit != nil {
+		e.edit()
+		e.edit = nil
+	}
+	return e.r.Read(p)
+}
+
+func TestRun(t *testing.T) {
+	live := map[string]any{
+		"features":       map[string]any{"a": true, "b": false},
+		"featureConfigs": map[string]any{},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": float64(10)},
+		},
+		"killSwitch": true,
+	}
+	file := `features:
+  a: true
+  c: true
+throttles:
+  search:
+    probability: 20
+    whitelist: [7]
+version: 99
+`
+	applied := map[string]any{
+		"features":       map[string]any{"a": true, "c": true},
+		"featureConfigs": map[string]any{},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": float64(20), "whitelist": []any{float64(7)}},
+		},
+		"killSwitch": true,
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("features:\n  a: null\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		args           []string
+		input          string
+		concurrentEdit bool
+		expectError    bool
+		expectedDoc    any
+	}{
+		{
+			name:        "no file",
+			expectError: true,
+			expectedDoc: live,
+		},
+		{
+			name:        "missing file",
+			args:        []string{"-f", filepath.Join(dir, "missing.yaml"), "--yes"},
+			expectError: true,
+			expectedDoc: live,
+		},
+		{
+			name:        "invalid file",
+			args:        []string{"-f", invalid, "--yes"},
+			expectError: true,
+			expectedDoc: live,
+		},
+		{
+			name:        "apply",
+			args:        []string{"-f", path, "--yes"},
+			expectedDoc: applied,
+		},
+		{
+			name:        "stdin",
+			args:        []string{"-f", "-", "--yes"},
+			input:       file,
+			expectedDoc: applied,
+		},
+		{
+			name:        "cancelled",
+			args:        []string{"-f", path},
+			input:       "n\n",
+			expectError: true,
+			expectedDoc: live,
+		},
+		{
+			name:        "confirmed",
+			args:        []string{"-f", path},
+			input:       "y\n",
+			expectedDoc: applied,
+		},
+		{
+			name:           "concurrent edit aborts",
+			args:           []string{"-f", path},
+			input:          "y\n",
+			concurrentEdit: true,
+			expectError:    true,
+			expectedDoc: map[string]any{
+				"features":       map[string]any{"a": true, "b": true},
+				"featureConfigs": map[string]any{},
+				"throttles": map[string]any{
+					"search": map[string]any{"probability": float64(10)},
+				},
+				"killSwitch": true,
+			},
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			f, err := attributevalue.MarshalMap(live)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(ctx, &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			in := &editingReader{r: strings.NewReader(tt.input)}
+			if tt.concurrentEdit {
+				in.edit = func() {
+					if err := store.SetFeature(ctx, "b", true); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			c := Command{Store: store, In: in, Out: new(bytes.Buffer)}
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(ctx, &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedDoc, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -1,17 +1,74 @@
 package config
 
-import "github.com/spf13/pflag"
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/spf13/pflag"
+)
 
 type Flags struct {
 	*pflag.FlagSet
 	TableName  string
 	RecordName string
+	Env        string
+	BreakGlass string
+	// ExpectVersion is the version the record must be at for writes to be applied, or -1 for any version.
+	ExpectVersion int64
+	// Actor and Reason are recorded in the audit log of writes.
+	Actor, Reason string
 }
 
 func GlobalFlags() *Flags {
 	f := Flags{}
 	f.FlagSet = pflag.NewFlagSet("global", pflag.ExitOnError)
+	// Subcommands define their own flags.
+	f.ParseErrorsWhitelist.UnknownFlags = true
 	f.StringVar(&f.TableName, "tableName", "featureFlagStore", "Define which dynamodb table to point to")
 	f.StringVar(&f.RecordName, "recordName", "features", "Define the partition key of the feature document")
+	f.StringVar(&f.Env, "env", "", "Define the environment of the feature document, e.g. staging (default is no environment)")
+	f.StringVar(&f.BreakGlass, "break-glass", "", "Allow writes to a frozen record, giving the reason")
+	f.Int64Var(&f.ExpectVersion, "expect-version", -1, "Refuse writes unless the record is at this version")
+	f.StringVar(&f.Actor, "actor", "", "Record who made a change in the audit log (default is the AWS caller identity)")
+	f.StringVar(&f.Reason, "reason", "", "Record why a change was made in the audit log")
 	return &f
 }
+
+// Record returns the partition key of the feature document in the selected environment.
+func (f *Flags) Record() string {
+	return dynamostore.EnvironmentRecord(f.RecordName, f.Env)
+}
+
+// ExpectedVersion returns the version passed with --expect-version, or nil if it was not passed.
+func (f *Flags) ExpectedVersion() *int64 {
+	if f.ExpectVersion < 0 {
+		return nil
+	}
+	v := f.ExpectVersion
+	return &v
+}
+
+// CallerIdentity returns the ARN of the AWS identity that the CLI is using.
+func CallerIdentity(region string) func(ctx context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		c, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+		if err != nil {
+			return "", err
+		}
+		out, err := sts.NewFromConfig(c).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			return "", err
+		}
+		return aws.ToString(out.Arn), nil
+	}
+}
+
+// CommandFlags returns a flag set for a subcommand, which ignores the global flags.
+func CommandFlags(name string) *pflag.FlagSet {
+	f := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	f.ParseErrorsWhitelist.UnknownFlags = true
+	return f
+}
@@ -0,0 +1,74 @@
+// Package dependents warns before changing features that other features or throttles have as a prerequisite.
+package dependents
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// Warn writes a warning for each of the features that other features or throttles of doc have as a prerequisite,
+// and returns whether there were any.
+func Warn(w io.Writer, doc models.StoreDocument, features ...string) bool {
+	warned := false
+	for _, feature := range features {
+		fs, ts := doc.Dependents(feature)
+		if len(fs) > 0 {
+			fmt.Fprintf(w, "Warning: features depend on %s: %s\n", feature, strings.Join(fs, ", "))
+		}
+		if len(ts) > 0 {
+			fmt.Fprintf(w, "Warning: throttles depend on %s: %s\n", feature, strings.Join(ts, ", "))
+		}
+		warned = warned || len(fs) > 0 || len(ts) > 0
+	}
+	return warned
+}
+
+// WarnChanges loads the document of the store and warns of the dependents of the features in changes.
+func WarnChanges(ctx context.Context, w io.Writer, store dynamostore.DynamoStore, changes []dynamostore.Change) error {
+	var features []string
+	for _, ch := range changes {
+		if ch.Type == "feature" {
+			features = append(features, ch.Name)
+		}
+	}
+	if len(features) == 0 {
+		return nil
+	}
+	doc, err := store.LoadDocument(ctx)
+	if errors.Is(err, dynamostore.ErrEmptyRecord) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error checking dependents: %s", err.Error())
+	}
+	Warn(w, doc, features...)
+	return nil
+}
+
+// Confirm loads the document of the store and warns of the dependents of features before they are changed.
+// If there are any, confirmation is read from in unless yes is set.
+func Confirm(ctx context.Context, in io.Reader, w io.Writer, store dynamostore.DynamoStore, yes bool, features ...string) error {
+	doc, err := store.LoadDocument(ctx)
+	if errors.Is(err, dynamostore.ErrEmptyRecord) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Error checking dependents: %s", err.Error())
+	}
+	if !Warn(w, doc, features...) || yes {
+		return nil
+	}
+	fmt.Fprint(w, "Continue? [y/N] ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+		return errors.New("Cancelled.")
+	}
+	return nil
+}
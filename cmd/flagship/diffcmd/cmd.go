@@ -0,0 +1,257 @@
+package diffcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/docfile"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// ErrDiffer is returned when the documents differ, after the differences have been printed.
+var ErrDiffer = errors.New("documents differ")
+
+// Command compares the features and throttles of two records, environments or files.
+type Command struct {
+	Store dynamostore.DynamoStore
+	// Record is the record name, without an environment.
+	Record string
+	In     io.Reader
+	Out    io.Writer
+}
+
+// Difference is a feature or throttle that differs between the two documents.
+type Difference struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Status is "added" if the entry is only in the second document, "removed" if it is only in the first, or "changed".
+	Status string  `json:"status"`
+	Fields []Field `json:"fields"`
+}
+
+// Field is a difference in a single field of an entry, such as the probability of a throttle.
+// Nested fields are named with dots, e.g. "config.enableAt".
+type Field struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old,omitempty"`
+	New   interface{} `json:"new,omitempty"`
+	// Added and Removed are set instead of Old and New for lists, such as the whitelist of a throttle.
+	Added   []interface{} `json:"added,omitempty"`
+	Removed []interface{} `json:"removed,omitempty"`
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("diff")
+	format := f.String("format", "text", "Format to write, text or json")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if f.NArg() != 2 {
+		c.Help()
+		return errors.New("Two documents must be provided.")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Unknown format %q, expected text or json.", *format)
+	}
+	ctx := context.Background()
+	a, err := c.load(ctx, f.Arg(0))
+	if err != nil {
+		return fmt.Errorf("Error loading %s: %s", f.Arg(0), err.Error())
+	}
+	b, err := c.load(ctx, f.Arg(1))
+	if err != nil {
+		return fmt.Errorf("Error loading %s: %s", f.Arg(1), err.Error())
+	}
+	changes, err := dynamostore.DiffDocuments(a, b)
+	if err != nil {
+		return err
+	}
+	diffs := make([]Difference, len(changes))
+	for i, ch := range changes {
+		diffs[i] = difference(ch)
+	}
+	if *format == "json" {
+		out := struct {
+			A           string       `json:"a"`
+			B           string       `json:"b"`
+			Differences []Difference `json:"differences"`
+		}{f.Arg(0), f.Arg(1), diffs}
+		enc := json.NewEncoder(c.Out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		c.print(diffs)
+	}
+	if len(diffs) > 0 {
+		return ErrDiffer
+	}
+	return nil
+}
+
+// load loads one side of the diff.
+// A side is a file if it is prefixed with file: or is an existing file, and "-" is stdin.
+// Otherwise it is an environment of the record if one exists, or else a record name.
+// The env: and record: prefixes choose explicitly, env: alone is the default environment.
+func (c Command) load(ctx context.Context, side string) (map[string]interface{}, error) {
+	if name := strings.TrimPrefix(side, "file:"); name != side || side == "-" {
+		return docfile.Read(name, c.In)
+	}
+	if fi, err := os.Stat(side); err == nil && !fi.IsDir() {
+		return docfile.Read(side, c.In)
+	}
+	store := c.Store
+	if env := strings.TrimPrefix(side, "env:"); env != side {
+		if err := dynamostore.ValidateEnvironment(env); err != nil {
+			return nil, err
+		}
+		store.Record = dynamostore.EnvironmentRecord(c.Record, env)
+		return loadRecord(ctx, store)
+	}
+	if record := strings.TrimPrefix(side, "record:"); record != side {
+		store.Record = record
+		return loadRecord(ctx, store)
+	}
+	if dynamostore.ValidateEnvironment(side) == nil {
+		store.Record = dynamostore.EnvironmentRecord(c.Record, side)
+		doc, err := loadRecord(ctx, store)
+		if !errors.Is(err, dynamostore.ErrEmptyRecord) {
+			return doc, err
+		}
+	}
+	store.Record = side
+	return loadRecord(ctx, store)
+}
+
+func loadRecord(ctx context.Context, store dynamostore.DynamoStore) (map[string]interface{}, error) {
+	doc, err := store.LoadRawDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, "_pk")
+	return doc, nil
+}
+
+func (c Command) print(diffs []Difference) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(c.Out, "No differences.")
+		return
+	}
+	for _, d := range diffs {
+		sign := map[string]string{"added": "+", "removed": "-", "changed": "~"}[d.Status]
+		fmt.Fprintf(c.Out, "%s %s %s\n", sign, d.Type, d.Name)
+		for _, fd := range d.Fields {
+			switch {
+			case fd.Added != nil || fd.Removed != nil:
+				var ids []string
+				for _, v := range fd.Added {
+					ids = append(ids, "+"+display(v))
+				}
+				for _, v := range fd.Removed {
+					ids = append(ids, "-"+display(v))
+				}
+				fmt.Fprintf(c.Out, "	%s: %s\n", fd.Field, strings.Join(ids, " "))
+			case fd.Old == nil:
+				fmt.Fprintf(c.Out, "	%s: %s\n", fd.Field, display(fd.New))
+			case fd.New == nil:
+				fmt.Fprintf(c.Out, "	%s: %s -> (missing)\n", fd.Field, display(fd.Old))
+			default:
+				fmt.Fprintf(c.Out, "	%s: %s -> %s\n", fd.Field, display(fd.Old), display(fd.New))
+			}
+		}
+	}
+	fmt.Fprintf(c.Out, "%d differences.\n", len(diffs))
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship diff [--format text|json] <a> <b>
+	Shows the features and throttles that differ between two documents, field by field.
+	Each side is a JSON or YAML file, an environment of the record, or a record name, in that order of preference.
+	Prefix a side with file:, env: or record: to choose, e.g. env: alone is the default environment and - is stdin.
+	Exits with status 1 if the documents differ, so it can be used to check for drift, and 2 if they cannot be compared:
+		flagship diff prod flags.yaml`)
+}
+
+func difference(ch dynamostore.Change) Difference {
+	d := Difference{Type: ch.Type, Name: ch.Name, Status: "changed"}
+	switch {
+	case ch.Old == nil:
+		d.Status = "added"
+	case ch.New == nil:
+		d.Status = "removed"
+	}
+	d.Fields = fields("", ch.Old, ch.New)
+	return d
+}
+
+// fields returns the differences between two values, descending into objects.
+func fields(prefix string, old, new interface{}) []Field {
+	om, ook := old.(map[string]interface{})
+	nm, nok := new.(map[string]interface{})
+	if (ook || old == nil) && (nok || new == nil) && (ook || nok) {
+		var keys []string
+		for k := range om {
+			keys = append(keys, k)
+		}
+		for k := range nm {
+			if _, ok := om[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var fs []Field
+		for _, k := range keys {
+			if !reflect.DeepEqual(om[k], nm[k]) {
+				fs = append(fs, fields(prefix+k+".", om[k], nm[k])...)
+			}
+		}
+		return fs
+	}
+	name := strings.TrimSuffix(prefix, ".")
+	ol, olok := old.([]interface{})
+	nl, nlok := new.([]interface{})
+	if (olok || old == nil) && (nlok || new == nil) && (olok || nlok) {
+		added, removed := subtract(nl, ol), subtract(ol, nl)
+		// Lists that only differ in order are shown whole.
+		if len(added) > 0 || len(removed) > 0 {
+			return []Field{{Field: name, Added: added, Removed: removed}}
+		}
+	}
+	return []Field{{Field: name, Old: old, New: new}}
+}
+
+// subtract returns the elements of a that are not in b.
+func subtract(a, b []interface{}) []interface{} {
+	var out []interface{}
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if reflect.DeepEqual(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func display(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
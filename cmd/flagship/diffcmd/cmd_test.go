@@ -0,0 +1,239 @@
+package diffcmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new any
+		expected []Field
+	}{
+		{
+			name:     "added feature",
+			new:      map[string]any{"value": true},
+			expected: []Field{{Field: "value", New: true}},
+		},
+		{
+			name: "throttle",
+			old:  map[string]any{"probability": float64(10), "whitelist": []any{float64(1), float64(2)}},
+			new:  map[string]any{"probability": float64(20), "whitelist": []any{float64(2), float64(3)}, "forceRejectAll": true},
+			expected: []Field{
+				{Field: "forceRejectAll", New: true},
+				{Field: "probability", Old: float64(10), New: float64(20)},
+				{Field: "whitelist", Added: []any{float64(3)}, Removed: []any{float64(1)}},
+			},
+		},
+		{
+			name: "nested",
+			old:  map[string]any{"value": false, "config": map[string]any{"enableAt": "a"}},
+			new:  map[string]any{"value": false, "config": map[string]any{"enableAt": "b"}},
+			expected: []Field{
+				{Field: "config.enableAt", Old: "a", New: "b"},
+			},
+		},
+		{
+			name: "reordered list",
+			old:  map[string]any{"allow": []any{"a", "b"}},
+			new:  map[string]any{"allow": []any{"b", "a"}},
+			expected: []Field{
+				{Field: "allow", Old: []any{"a", "b"}, New: []any{"b", "a"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, fields("", tt.old, tt.new)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	staging := map[string]any{
+		"features": map[string]any{"a": true, "b": true},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 50, "whitelist": []uint{1, 2}},
+		},
+	}
+	prod := map[string]any{
+		"features": map[string]any{"a": false, "c": true},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 10, "whitelist": []uint{1}},
+		},
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "flags.yaml")
+	if err := os.WriteFile(path, []byte("features:\n  a: false\n  c: true\nthrottles:\n  search:\n    probability: 10\n    whitelist: [1]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name         string
+		args         []string
+		input        string
+		expectError  bool
+		expectDiffer bool
+		expected     string
+	}{
+		{
+			name:         "environments",
+			args:         []string{"prod", "staging"},
+			expectDiffer: true,
+			expected: `~ feature a
+	value: false -> true
++ feature b
+	value: true
+- feature c
+	value: true -> (missing)
+~ throttle search
+	probability: 10 -> 50
+	whitelist: +2
+4 differences.
+`,
+		},
+		{
+			name:     "environment and file",
+			args:     []string{"prod", path},
+			expected: "No differences.\n",
+		},
+		{
+			name:     "environment and stdin",
+			args:     []string{"env:prod", "-"},
+			input:    `{"features": {"a": false, "c": true}, "throttles": {"search": {"probability": 10, "whitelist": [1]}}}`,
+			expected: "No differences.\n",
+		},
+		{
+			name:     "json",
+			args:     []string{"--format", "json", "prod", "file:" + path},
+			expected: "{\n  \"a\": \"prod\",\n  \"b\": \"file:" + path + "\",\n  \"differences\": []\n}\n",
+		},
+		{
+			name:         "json differences",
+			args:         []string{"--format", "json", path, "staging"},
+			expectDiffer: true,
+			expected: `{
+  "a": "` + path + `",
+  "b": "staging",
+  "differences": [
+    {
+      "type": "feature",
+      "name": "a",
+      "status": "changed",
+      "fields": [
+        {
+          "field": "value",
+          "old": false,
+          "new": true
+        }
+      ]
+    },
+    {
+      "type": "feature",
+      "name": "b",
+      "status": "added",
+      "fields": [
+        {
+          "field": "value",
+          "new": true
+        }
+      ]
+    },
+    {
+      "type": "feature",
+      "name": "c",
+      "status": "removed",
+      "fields": [
+        {
+          "field": "value",
+          "old": true
+        }
+      ]
+    },
+    {
+      "type": "throttle",
+      "name": "search",
+      "status": "changed",
+      "fields": [
+        {
+          "field": "probability",
+          "old": 10,
+          "new": 50
+        },
+        {
+          "field": "whitelist",
+          "added": [
+            2
+          ]
+        }
+      ]
+    }
+  ]
+}
+`,
+		},
+		{
+			name:        "missing record",
+			args:        []string{"prod", "nothing"},
+			expectError: true,
+		},
+		{
+			name:        "one side",
+			args:        []string{"prod"},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			for env, doc := range map[string]any{"staging": staging, "prod": prod} {
+				f, err := attributevalue.MarshalMap(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: dynamostore.EnvironmentRecord(record, env)}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			out := new(bytes.Buffer)
+			c := Command{Store: store, Record: record, In: strings.NewReader(tt.input), Out: out}
+			err := c.Run(tt.args)
+			switch {
+			case tt.expectDiffer && !errors.Is(err, ErrDiffer):
+				t.Errorf("Command{}.Run(...) = %v, expected ErrDiffer", err)
+			case !tt.expectDiffer && !tt.expectError && err != nil:
+				t.Errorf("Command{}.Run(...) = %v", err)
+			case tt.expectError && err == nil:
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			if tt.expected == "" {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
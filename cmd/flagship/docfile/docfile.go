@@ -0,0 +1,132 @@
+// Package docfile reads and writes feature documents as JSON or YAML files.
+package docfile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats are the formats that documents can be written in.
+var Formats = []string{"yaml", "json"}
+
+// Read reads a JSON or YAML document from a file, or from stdin if name is "-".
+// The document is returned as it would be decoded from JSON, with float64 numbers.
+func Read(name string, stdin io.Reader) (map[string]interface{}, error) {
+	var b []byte
+	var err error
+	if name == "-" {
+		if stdin == nil {
+			return nil, errors.New("no stdin")
+		}
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return Decode(b)
+}
+
+// Decode decodes a JSON or YAML document, as it would be decoded from JSON.
+func Decode(b []byte) (map[string]interface{}, error) {
+	// YAML is a superset of JSON, so both are decoded as YAML.
+	var v interface{}
+	if err := yaml.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	v, err := jsonValue(v)
+	if err != nil {
+		return nil, err
+	}
+	// Round trip through JSON so that numbers are float64.
+	j, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(j, &doc); err != nil {
+		return nil, errors.New("document must be an object")
+	}
+	return doc, nil
+}
+
+// jsonValue converts the maps decoded from YAML, which can have keys of any type, to maps with string keys.
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			s, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys must be strings, got %v", k)
+			}
+			c, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			m[s] = c
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			c, err := jsonValue(e)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = c
+		}
+		return l, nil
+	}
+	return v, nil
+}
+
+// Write writes a document in one of Formats, with keys in sorted order so that output is stable.
+func Write(w io.Writer, doc map[string]interface{}, format string) error {
+	var b []byte
+	var err error
+	switch format {
+	case "json":
+		b, err = json.MarshalIndent(doc, "", "  ")
+		b = append(b, '\n')
+	case "yaml":
+		b, err = yaml.Marshal(yamlValue(doc))
+	default:
+		return fmt.Errorf("unknown format %q, must be yaml or json", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// yamlValue converts whole numbers to integers, which YAML would otherwise write in exponent form when large.
+func yamlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = yamlValue(e)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, e := range v {
+			l[i] = yamlValue(e)
+		}
+		return l
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	}
+	return v
+}
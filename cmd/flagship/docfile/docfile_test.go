@@ -0,0 +1,144 @@
+package docfile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    map[string]interface{}
+		expectError bool
+	}{
+		{
+			name:  "json",
+			input: `{"features": {"a": true}, "throttles": {"t": {"probability": 2.5, "whitelist": [10]}}}`,
+			expected: map[string]interface{}{
+				"features":  map[string]interface{}{"a": true},
+				"throttles": map[string]interface{}{"t": map[string]interface{}{"probability": 2.5, "whitelist": []interface{}{float64(10)}}},
+			},
+		},
+		{
+			name: "yaml",
+			input: `features:
+  a: true
+featureConfigs:
+  a:
+    enableAt: 2022-11-25T00:00:00Z
+throttles:
+  t:
+    probability: 10
+    buckets: 1000000
+`,
+			expected: map[string]interface{}{
+				"features":       map[string]interface{}{"a": true},
+				"featureConfigs": map[string]interface{}{"a": map[string]interface{}{"enableAt": "2022-11-25T00:00:00Z"}},
+				"throttles":      map[string]interface{}{"t": map[string]interface{}{"probability": float64(10), "buckets": float64(1000000)}},
+			},
+		},
+		{
+			name:        "not an object",
+			input:       `[1, 2]`,
+			expectError: true,
+		},
+		{
+			name:        "non string keys",
+			input:       "features:\n  1: true\n",
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Decode([]byte(tt.input))
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, doc); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	doc := map[string]interface{}{
+		"throttles": map[string]interface{}{"t": map[string]interface{}{"probability": 2.5, "buckets": float64(1000000)}},
+		"features":  map[string]interface{}{"b": false, "a": true},
+		"featureConfigs": map[string]interface{}{
+			"a": map[string]interface{}{"enableAt": "2022-11-25T00:00:00Z"},
+		},
+	}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "yaml",
+			expected: `featureConfigs:
+  a:
+    enableAt: "2022-11-25T00:00:00Z"
+features:
+  a: true
+  b: false
+throttles:
+  t:
+    buckets: 1000000
+    probability: 2.5
+`,
+		},
+		{
+			format: "json",
+			expected: `{
+  "featureConfigs": {
+    "a": {
+      "enableAt": "2022-11-25T00:00:00Z"
+    }
+  },
+  "features": {
+    "a": true,
+    "b": false
+  },
+  "throttles": {
+    "t": {
+      "buckets": 1000000,
+      "probability": 2.5
+    }
+  }
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.format, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, doc, tt.format); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, b.String()); diff != "" {
+				t.Error(diff)
+			}
+			roundTrip, err := Decode(b.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(doc, roundTrip); diff != "" {
+				t.Errorf("round trip: %s", diff)
+			}
+		})
+	}
+	if err := Write(&bytes.Buffer{}, doc, "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,110 @@
+package doctorcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command checks that the table and record can be used, reporting the result of each check.
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+// check is the outcome of one check. A nil err is a pass, and skipped checks depend on one that failed.
+type check struct {
+	name    string
+	err     error
+	skipped bool
+	details []string
+}
+
+func (c Command) Run(args []string) error {
+	ctx := context.Background()
+	var checks []check
+	add := func(name string, err error, details ...string) bool {
+		checks = append(checks, check{name: name, err: err, details: details})
+		return err == nil
+	}
+	skip := func(names ...string) {
+		for _, n := range names {
+			checks = append(checks, check{name: n, skipped: true})
+		}
+	}
+	table := fmt.Sprintf("connect to table %s", c.Store.TableName)
+	keys := "table has a _pk string hash key"
+	read := fmt.Sprintf("read record %s", c.Store.Record)
+	exists := fmt.Sprintf("record %s exists", c.Store.Record)
+	valid := fmt.Sprintf("document is valid against schema v%d", flagship.SchemaVersion)
+	write := fmt.Sprintf("write to record %s and its audit log", c.Store.Record)
+	t, err := c.Store.DescribeTable(ctx)
+	if errors.Is(err, dynamostore.ErrTableNotFound) {
+		err = fmt.Errorf("%w, run flagship init", err)
+	}
+	if !add(table, err) {
+		skip(keys, read, exists, valid, write)
+		return c.report(checks)
+	}
+	if !add(keys, dynamostore.CheckKeySchema(t)) {
+		skip(read, exists, valid, write)
+		return c.report(checks)
+	}
+	doc, err := c.Store.LoadRawDocument(ctx)
+	switch {
+	case errors.Is(err, dynamostore.ErrEmptyRecord):
+		add(read, nil)
+		add(exists, errors.New("the record does not exist, run flagship init"))
+		skip(valid)
+	case err != nil:
+		add(read, err)
+		skip(exists, valid)
+	default:
+		add(read, nil)
+		add(exists, nil)
+		problems := flagship.ValidateDocument(doc)
+		var details []string
+		for _, p := range problems {
+			details = append(details, p.Error())
+		}
+		if len(problems) > 0 {
+			err = fmt.Errorf("%d problems found", len(problems))
+		}
+		add(valid, err, details...)
+	}
+	add(write, c.Store.CheckWriteAccess(ctx))
+	return c.report(checks)
+}
+
+func (c Command) report(checks []check) error {
+	failed := 0
+	for _, ch := range checks {
+		switch {
+		case ch.skipped:
+			fmt.Fprintf(c.Out, "[skip] %s\n", ch.name)
+		case ch.err != nil:
+			failed++
+			fmt.Fprintf(c.Out, "[FAIL] %s: %s\n", ch.name, ch.err.Error())
+		default:
+			fmt.Fprintf(c.Out, "[ok]   %s\n", ch.name)
+		}
+		for _, d := range ch.details {
+			fmt.Fprintf(c.Out, "       %s\n", d)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship doctor
+	Checks that the table can be reached and has the right key schema, that the record exists and is a valid document,
+	and that the credentials in use can read and write it. Each check is reported, and checks that depend on a failed one are skipped.
+	Writing is checked without changing anything.`)
+}
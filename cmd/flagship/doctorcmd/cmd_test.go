@@ -0,0 +1,126 @@
+package doctorcmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       string
+		document    any
+		expectError bool
+		expectedOut string
+	}{
+		{
+			name:     "healthy",
+			document: map[string]any{"features": map[string]any{"aFeature": true}},
+			expectedOut: `[ok]   connect to table {table}
+[ok]   table has a _pk string hash key
+[ok]   read record {record}
+[ok]   record {record} exists
+[ok]   document is valid against schema v2
+[ok]   write to record {record} and its audit log
+`,
+		},
+		{
+			name:        "missing table",
+			table:       "missing",
+			expectError: true,
+			expectedOut: `[FAIL] connect to table missing: table not found: missing, run flagship init
+[skip] table has a _pk string hash key
+[skip] read record {record}
+[skip] record {record} exists
+[skip] document is valid against schema v2
+[skip] write to record {record} and its audit log
+`,
+		},
+		{
+			name:        "missing record",
+			expectError: true,
+			expectedOut: `[ok]   connect to table {table}
+[ok]   table has a _pk string hash key
+[ok]   read record {record}
+[FAIL] record {record} exists: the record does not exist, run flagship init
+[skip] document is valid against schema v2
+[ok]   write to record {record} and its audit log
+`,
+		},
+		{
+			name: "invalid document",
+			document: map[string]any{
+				"features":  map[string]any{"aFeature": nil},
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": "5"}},
+			},
+			expectError: true,
+			expectedOut: `[ok]   connect to table {table}
+[ok]   table has a _pk string hash key
+[ok]   read record {record}
+[ok]   record {record} exists
+[FAIL] document is valid against schema v2: 2 problems found
+       features.aFeature: must be a boolean, a string, a number, an object or an array, got null
+       throttles.aThrottle.probability: must be a number, got string "5"
+[ok]   write to record {record} and its audit log
+`,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			table := name
+			if tt.table != "" {
+				table = tt.table
+			}
+			if tt.document != nil {
+				f, err := attributevalue.MarshalMap(tt.document)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			out := new(bytes.Buffer)
+			c := Command{Store: dynamostore.NewDynamoStoreWithClient(table, record, dclient), Out: out}
+			err := c.Run(nil)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			expectedOut := strings.NewReplacer("{table}", table, "{record}", record).Replace(tt.expectedOut)
+			if diff := cmp.Diff(expectedOut, out.String()); diff != "" {
+				t.Error(diff)
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: dynamostore.AuditKey(record, 0)},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(i.Item) > 0 {
+				t.Error("the write check wrote an audit entry")
+			}
+		})
+	}
+}
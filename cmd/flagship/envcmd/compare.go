@@ -0,0 +1,56 @@
+package envcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Compare struct {
+	Store dynamostore.DynamoStore
+	// Record is the record name, without an environment.
+	Record string
+	Out    io.Writer
+}
+
+func (c Compare) Run(args []string) error {
+	if len(args) < 1 {
+		c.Help()
+		return errors.New("No flagName provided.")
+	}
+	ctx := context.Background()
+	envs, err := c.Store.ListEnvironments(ctx, c.Record)
+	if err != nil {
+		return fmt.Errorf("Error listing environments: %s", err.Error())
+	}
+	w := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ENVIRONMENT\t%s\n", args[0])
+	for _, e := range envs {
+		store := c.Store
+		store.Record = dynamostore.EnvironmentRecord(c.Record, e)
+		doc, err := store.LoadDocument(ctx)
+		if err != nil {
+			return fmt.Errorf("Error loading %s: %s", envName(e), err.Error())
+		}
+		value := "(missing)"
+		if f, ok := doc.Features[args[0]]; ok {
+			value = fmt.Sprint(f)
+		} else if t, ok := doc.Throttles[args[0]]; ok {
+			value = fmt.Sprintf("probability %v", t.Probability)
+			if t.ForceRejectAll {
+				value += ", forceRejectAll"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", envName(e), value)
+	}
+	return w.Flush()
+}
+
+func (c Compare) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship env compare [flagName]
+	Shows the value of a feature or throttle in every environment.`)
+}
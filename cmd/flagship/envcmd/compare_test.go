@@ -0,0 +1,102 @@
+package envcmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestCompareRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		documents   map[string]any
+		expectError bool
+		expectedOut string
+	}{
+		{
+			name:        "no args",
+			expectError: true,
+			expectedOut: `usage: flagship env compare [flagName]
+	Shows the value of a feature or throttle in every environment.` + "\n",
+		},
+		{
+			name: "feature in some environments",
+			args: []string{"aFeature"},
+			documents: map[string]any{
+				"": map[string]any{
+					"features": map[string]any{"aFeature": true},
+				},
+				"staging": map[string]any{
+					"features": map[string]any{"aFeature": false},
+				},
+				"prod": map[string]any{
+					"features": map[string]any{},
+				},
+			},
+			expectedOut: "ENVIRONMENT  aFeature\n" +
+				"(default)    true\n" +
+				"prod         (missing)\n" +
+				"staging      false\n",
+		},
+		{
+			name: "throttle",
+			args: []string{"aThrottle"},
+			documents: map[string]any{
+				"staging": map[string]any{
+					"throttles": map[string]any{
+						"aThrottle": map[string]any{"probability": 50},
+					},
+				},
+				"prod": map[string]any{
+					"throttles": map[string]any{
+						"aThrottle": map[string]any{"probability": 5, "forceRejectAll": true},
+					},
+				},
+			},
+			expectedOut: "ENVIRONMENT  aThrottle\n" +
+				"prod         probability 5, forceRejectAll\n" +
+				"staging      probability 50\n",
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := Compare{Store: store, Record: record, Out: out}
+			for env, doc := range tt.documents {
+				es := store
+				es.Record = dynamostore.EnvironmentRecord(record, env)
+				if err := es.InitRecord(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+				p, err := es.PlanApply(context.Background(), doc.(map[string]any))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := es.ApplyPromotion(context.Background(), p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Compare{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Compare{}.Run(...) = nil")
+			}
+			if diff := cmp.Diff(tt.expectedOut, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package envcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Ls struct {
+	Store dynamostore.DynamoStore
+	// Record is the record name, without an environment.
+	Record string
+	Out    io.Writer
+}
+
+func (l Ls) Run(args []string) error {
+	envs, err := l.Store.ListEnvironments(context.Background(), l.Record)
+	if err != nil {
+		return fmt.Errorf("Error listing environments: %s", err.Error())
+	}
+	for _, e := range envs {
+		fmt.Fprintln(l.Out, envName(e))
+	}
+	return nil
+}
+
+func (l Ls) Help() {
+	fmt.Fprintln(l.Out, `usage: flagship env ls
+	Lists the environments that have a feature document.`)
+}
+
+func envName(env string) string {
+	if env == "" {
+		return "(default)"
+	}
+	return env
+}
@@ -0,0 +1,66 @@
+package envcmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestLsRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     []string
+		expectedOut string
+	}{
+		{
+			name:        "no environments",
+			expectedOut: "",
+		},
+		{
+			name:        "default environment only",
+			records:     []string{"{record}"},
+			expectedOut: "(default)\n",
+		},
+		{
+			name:        "several environments",
+			records:     []string{"{record}", "{record}#staging", "{record}#prod", "{record}-other#dev"},
+			expectedOut: "(default)\nprod\nstaging\n",
+		},
+		{
+			name:        "audit entries and segments are not environments",
+			records:     []string{"{record}", "_audit/{record}/1", "_audit/{record}#staging/1", "_segment/{record}"},
+			expectedOut: "(default)\n",
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := Ls{Store: store, Record: record, Out: out}
+			for _, r := range tt.records {
+				rs := store
+				rs.Record = strings.ReplaceAll(r, "{record}", record)
+				if err := rs.InitRecord(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := c.Run(nil)
+			if err != nil {
+				t.Errorf("Ls{}.Run(...) = %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedOut, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
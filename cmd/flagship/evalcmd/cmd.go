@@ -0,0 +1,80 @@
+package evalcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command evaluates a feature or throttle as the library would, now or at a time in the past.
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("eval")
+	at := f.String("at", "", "RFC3339 time to evaluate at, using the document as it was then")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if f.NArg() < 1 {
+		c.Help()
+		return errors.New("No flagName provided.")
+	}
+	ctx := context.Background()
+	fs, err := flagship.New(ctx,
+		flagship.WithClient(c.Store.Client),
+		flagship.WithTableName(c.Store.TableName),
+		flagship.WithRecordName(c.Store.Record),
+	)
+	if err != nil {
+		return err
+	}
+	var snap *flagship.Snapshot
+	if *at == "" {
+		snap, err = fs.(flagship.Snapshotter).Snapshot(ctx)
+	} else {
+		t, perr := time.Parse(time.RFC3339, *at)
+		if perr != nil {
+			return fmt.Errorf("Invalid --at: %s", perr.Error())
+		}
+		snap, err = fs.(flagship.TimeTraveler).SnapshotAt(ctx, t)
+	}
+	if err != nil {
+		return fmt.Errorf("Error loading document: %s", err.Error())
+	}
+	key := f.Arg(0)
+	flag, ok := snap.Flag(key)
+	if !ok {
+		return fmt.Errorf("%s does not exist", key)
+	}
+	fmt.Fprintf(c.Out, "Evaluated against version %d\n", snap.Version())
+	if flag.Type == flagship.FeatureFlag {
+		fmt.Fprintf(c.Out, "%s: %v\n", key, snap.Bool(ctx, key))
+		return nil
+	}
+	if f.NArg() < 2 {
+		return errors.New("No hashKey provided for throttle.")
+	}
+	hashKey := f.Arg(1)
+	allowed := snap.ThrottleAllow(ctx, key, strings.NewReader(hashKey))
+	hash := snap.GetHash(ctx, key, strings.NewReader(hashKey))
+	fmt.Fprintf(c.Out, "%s for %s: %v (hash %d)\n", key, hashKey, allowed, hash)
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship eval [flagName] [hashKey] [--at <time>]
+	Evaluates a feature, or a throttle for a hash key, as the library would.
+	With --at the document is rebuilt from the audit log as it was at that RFC3339 time,
+	and schedules and ramps are evaluated at that time. Segments are not versioned, so their current members are used.`)
+}
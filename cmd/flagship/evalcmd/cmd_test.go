@@ -0,0 +1,121 @@
+package evalcmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	ctx := context.Background()
+	record := uuid.NewString()
+	f, err := attributevalue.MarshalMap(map[string]any{
+		"features": map[string]any{"newCheckout": false},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 100},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f["_pk"] = &types.AttributeValueMemberS{Value: record}
+	_, err = dclient.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      f,
+		TableName: &name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+	// RFC3339 has a resolution of a second, so wait for the next second between writes.
+	tick := func() string {
+		time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+		at := time.Now().UTC().Format(time.RFC3339)
+		time.Sleep(time.Second)
+		return at
+	}
+	if err := store.SetFeature(ctx, "newCheckout", true); err != nil {
+		t.Fatal(err)
+	}
+	launched := tick()
+	if err := store.SetThrottleProbability(ctx, "search", 0); err != nil {
+		t.Fatal(err)
+	}
+	hash := flagship.GetHash(ctx, "search", strings.NewReader("user123"))
+	tests := []struct {
+		name           string
+		args           []string
+		expectError    bool
+		expectedOutput string
+	}{
+		{
+			name:        "no args",
+			expectError: true,
+		},
+		{
+			name:           "feature now",
+			args:           []string{"newCheckout"},
+			expectedOutput: "Evaluated against version 2\nnewCheckout: true\n",
+		},
+		{
+			name:           "throttle now",
+			args:           []string{"search", "user123"},
+			expectedOutput: fmt.Sprintf("Evaluated against version 2\nsearch for user123: false (hash %d)\n", hash),
+		},
+		{
+			name:           "throttle at a time",
+			args:           []string{"search", "user123", "--at", launched},
+			expectedOutput: fmt.Sprintf("Evaluated against version 1\nsearch for user123: true (hash %d)\n", hash),
+		},
+		{
+			name:        "throttle without hash key",
+			args:        []string{"search"},
+			expectError: true,
+		},
+		{
+			name:        "missing flag",
+			args:        []string{"other"},
+			expectError: true,
+		},
+		{
+			name:        "invalid time",
+			args:        []string{"newCheckout", "--at", "yesterday"},
+			expectError: true,
+		},
+		{
+			name:        "before history",
+			args:        []string{"newCheckout", "--at", "2000-01-01T00:00:00Z"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := Command{Store: store, Out: &out}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			if tt.expectedOutput != "" && out.String() != tt.expectedOutput {
+				t.Errorf("expected output %q, got %q", tt.expectedOutput, out.String())
+			}
+		})
+	}
+}
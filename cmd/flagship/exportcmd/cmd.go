@@ -0,0 +1,39 @@
+package exportcmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/docfile"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command writes the whole feature document of the record, so that it can be kept in version control.
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("export")
+	format := f.String("format", "yaml", "Format to write, yaml or json")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	doc, err := c.Store.LoadRawDocument(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error when loading document: %s", err.Error())
+	}
+	delete(doc, "_pk")
+	return docfile.Write(c.Out, doc, *format)
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship export [--format yaml|json]
+	Writes the feature document of the record to stdout, with keys in a stable order:
+		flagship export > flags.yaml
+	The file can be changed and applied with flagship apply.`)
+}
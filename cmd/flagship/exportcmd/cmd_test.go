@@ -0,0 +1,102 @@
+package exportcmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	doc := map[string]any{
+		"features": map[string]any{"b": false, "a": true},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 50, "whitelist": []uint{7}},
+		},
+		"version": 3,
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{
+			name: "yaml",
+			expected: `features:
+  a: true
+  b: false
+throttles:
+  search:
+    probability: 50
+    whitelist:
+    - 7
+version: 3
+`,
+		},
+		{
+			name: "json",
+			args: []string{"--format", "json"},
+			expected: `{
+  "features": {
+    "a": true,
+    "b": false
+  },
+  "throttles": {
+    "search": {
+      "probability": 50,
+      "whitelist": [
+        7
+      ]
+    }
+  },
+  "version": 3
+}
+`,
+		},
+		{
+			name:        "unknown format",
+			args:        []string{"--format", "toml"},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			f, err := attributevalue.MarshalMap(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			out := new(bytes.Buffer)
+			c := Command{Store: dynamostore.NewDynamoStoreWithClient(name, record, dclient), Out: out}
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Command{}.Run(...) = nil")
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.expected, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
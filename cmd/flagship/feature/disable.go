@@ -4,27 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
 	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
 type Disable struct {
 	Store dynamostore.DynamoStore
+	// In is read for confirmation when other flags depend on the feature.
+	In io.Reader
 }
 
 func (d Disable) Run(args []string) error {
-	if len(args) < 1 {
+	f := config.CommandFlags("disable")
+	yes := f.BoolP("yes", "y", false, "Disable without asking for confirmation when other flags depend on the feature")
+	if err := f.Parse(args); err != nil {
+		d.Help()
+		return err
+	}
+	if f.NArg() < 1 {
 		d.Help()
 		return errors.New("No featureName provided.")
 	}
-	err := d.Store.SetFeature(context.Background(), args[0], false)
+	ctx := context.Background()
+	if err := dependents.Confirm(ctx, d.In, os.Stderr, d.Store, *yes, f.Arg(0)); err != nil {
+		return err
+	}
+	err := d.Store.SetFeature(ctx, f.Arg(0), false)
 	if err != nil {
 		return fmt.Errorf("Error when setting flag: %s", err.Error())
 	}
-	fmt.Printf("%v: %v\n", args[0], false)
+	fmt.Printf("%v: %v\n", f.Arg(0), false)
 	return nil
 }
 func (Disable) Help() {
-	fmt.Println(`usage: flagship feature disable [featureName]
-	Disables a feature flag.`)
+	fmt.Println(`usage: flagship feature disable [featureName] [--yes]
+	Disables a feature flag.
+	If other features or throttles depend on it, confirmation is asked for unless --yes is given.`)
 }
@@ -2,6 +2,7 @@ package feature
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -17,6 +18,7 @@ func TestDisableRun(t *testing.T) {
 	tests := []struct {
 		name             string
 		args             []string
+		input            string
 		features         any
 		expectError      bool
 		expectedFeatures any
@@ -74,6 +76,57 @@ func TestDisableRun(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name:  "dependents cancelled",
+			args:  []string{"aFeature"},
+			input: "n\n",
+			features: map[string]any{
+				"features": map[string]any{"aFeature": true, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": true, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name:  "dependents confirmed",
+			args:  []string{"aFeature"},
+			input: "y\n",
+			features: map[string]any{
+				"features": map[string]any{"aFeature": true, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+		},
+		{
+			name: "dependents with --yes",
+			args: []string{"aFeature", "--yes"},
+			features: map[string]any{
+				"features": map[string]any{"aFeature": true, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false, "bFeature": true},
+				"featureConfigs": map[string]any{
+					"bFeature": map[string]any{"prerequisites": []any{map[string]any{"flag": "aFeature"}}},
+				},
+			},
+		},
 	}
 	name, dclient, close := dynamotesting.CreateLocalTable(t)
 	defer close()
@@ -82,7 +135,7 @@ func TestDisableRun(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			record := uuid.NewString()
 			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
-			c := Disable{Store: store}
+			c := Disable{Store: store, In: strings.NewReader(tt.input)}
 			if tt.features != nil {
 				f, err := attributevalue.MarshalMap(tt.features)
 				if err != nil {
@@ -116,6 +169,7 @@ func TestDisableRun(t *testing.T) {
 				t.Fatal(err)
 			}
 			delete(res, "_pk")
+			delete(res, "version")
 			if diff := cmp.Diff(tt.expectedFeatures, res); diff != "" {
 				t.Error(diff)
 			}
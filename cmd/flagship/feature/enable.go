@@ -4,27 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
 	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
 type Enable struct {
 	Store dynamostore.DynamoStore
+	// In is read for confirmation when other flags depend on the feature.
+	In io.Reader
 }
 
 func (e Enable) Run(args []string) error {
-	if len(args) < 1 {
+	f := config.CommandFlags("enable")
+	yes := f.BoolP("yes", "y", false, "Enable without asking for confirmation when other flags depend on the feature")
+	if err := f.Parse(args); err != nil {
+		e.Help()
+		return err
+	}
+	if f.NArg() < 1 {
 		e.Help()
 		return errors.New("No featureName provided.")
 	}
-	err := e.Store.SetFeature(context.Background(), args[0], true)
+	ctx := context.Background()
+	if err := dependents.Confirm(ctx, e.In, os.Stderr, e.Store, *yes, f.Arg(0)); err != nil {
+		return err
+	}
+	err := e.Store.SetFeature(ctx, f.Arg(0), true)
 	if err != nil {
 		return fmt.Errorf("Error when setting flag: %s", err.Error())
 	}
-	fmt.Printf("%v: %v\n", args[0], true)
+	fmt.Printf("%v: %v\n", f.Arg(0), true)
 	return nil
 }
 func (Enable) Help() {
-	fmt.Println(`usage: flagship feature enable [featureName]
-	Enables a feature flag.`)
+	fmt.Println(`usage: flagship feature enable [featureName] [--yes]
+	Enables a feature flag.
+	If other features or throttles depend on it, confirmation is asked for unless --yes is given.`)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -18,6 +19,8 @@ func TestEnableRun(t *testing.T) {
 		name             string
 		args             []string
 		features         any
+		breakGlass       string
+		expectVersion    *int64
 		expectError      bool
 		expectedFeatures any
 	}{
@@ -41,6 +44,7 @@ func TestEnableRun(t *testing.T) {
 				"features": map[string]any{
 					"aFeature": true,
 				},
+				"version": float64(1),
 			},
 			expectError: false,
 		},
@@ -56,6 +60,7 @@ func TestEnableRun(t *testing.T) {
 				"features": map[string]any{
 					"aFeature": true,
 				},
+				"version": float64(1),
 			},
 			expectError: false,
 		},
@@ -71,9 +76,104 @@ func TestEnableRun(t *testing.T) {
 				"features": map[string]any{
 					"aFeature": true,
 				},
+				"version": float64(1),
 			},
 			expectError: false,
 		},
+		{
+			name: "frozen record",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"freeze": map[string]any{
+					"reason": "incident",
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"freeze": map[string]any{
+					"reason": "incident",
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "frozen record with break glass",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"freeze": map[string]any{
+					"reason": "incident",
+				},
+			},
+			breakGlass: "fixing incident",
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"freeze": map[string]any{
+					"reason": "incident",
+				},
+				"version": float64(1),
+			},
+		},
+		{
+			name: "expected version matches",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"version": 3,
+			},
+			expectVersion: aws.Int64(3),
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"version": float64(4),
+			},
+		},
+		{
+			name: "expected version of unversioned record",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+			},
+			expectVersion: aws.Int64(0),
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"version": float64(1),
+			},
+		},
+		{
+			name: "expected version does not match",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"version": 4,
+			},
+			expectVersion: aws.Int64(3),
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+				"version": float64(4),
+			},
+			expectError: true,
+		},
 	}
 	name, dclient, close := dynamotesting.CreateLocalTable(t)
 	defer close()
@@ -82,6 +182,8 @@ func TestEnableRun(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			record := uuid.NewString()
 			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			store.BreakGlass = tt.breakGlass
+			store.ExpectVersion = tt.expectVersion
 			c := Enable{Store: store}
 			if tt.features != nil {
 				f, err := attributevalue.MarshalMap(tt.features)
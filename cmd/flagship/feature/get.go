@@ -27,7 +27,7 @@ func (g Get) Run(args []string) error {
 	if !ok {
 		return fmt.Errorf("No feature found: %s", args[0])
 	}
-	fmt.Fprintf(g.Out, "%s: %v\n", args[0], fe)
+	fmt.Fprintln(g.Out, FormatValue(args[0], fe, true))
 	return nil
 }
 func (g Get) Help() {
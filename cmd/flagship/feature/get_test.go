@@ -95,6 +95,42 @@ func TestGetRun(t *testing.T) {
 			args:        []string{"aFeature"},
 			expectedOut: "aFeature: false\n",
 		},
+		{
+			name: "string feature",
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": "Welcome",
+				},
+			},
+			args:        []string{"aFeature"},
+			expectedOut: "aFeature (string): \"Welcome\"\n",
+		},
+		{
+			name: "number feature",
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": 2.5,
+				},
+			},
+			args:        []string{"aFeature"},
+			expectedOut: "aFeature (number): 2.5\n",
+		},
+		{
+			name: "json feature",
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": map[string]any{"daily": 10.0, "regions": []any{"eu"}},
+				},
+			},
+			args: []string{"aFeature"},
+			expectedOut: `aFeature (json): {
+  "daily": 10,
+  "regions": [
+    "eu"
+  ]
+}
+`,
+		},
 	}
 	name, dclient, close := dynamotesting.CreateLocalTable(t)
 	defer close()
@@ -138,6 +174,7 @@ func TestGetRun(t *testing.T) {
 				t.Fatal(err)
 			}
 			delete(res, "_pk")
+			delete(res, "version")
 			if diff := cmp.Diff(tt.features, res); diff != "" {
 				t.Error(diff)
 			}
@@ -4,27 +4,44 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
 	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
 type Rm struct {
 	Store dynamostore.DynamoStore
+	// In is read for confirmation when other flags depend on the feature.
+	In io.Reader
 }
 
 func (r Rm) Run(args []string) error {
-	if len(args) < 1 {
+	f := config.CommandFlags("rm")
+	yes := f.BoolP("yes", "y", false, "Remove without asking for confirmation when other flags depend on the feature")
+	if err := f.Parse(args); err != nil {
+		r.Help()
+		return err
+	}
+	if f.NArg() < 1 {
 		r.Help()
 		return errors.New("No featureName provided.")
 	}
-	err := r.Store.RemoveFeature(context.Background(), args[0])
+	ctx := context.Background()
+	if err := dependents.Confirm(ctx, r.In, os.Stderr, r.Store, *yes, f.Arg(0)); err != nil {
+		return err
+	}
+	err := r.Store.RemoveFeature(ctx, f.Arg(0))
 	if err != nil {
 		return fmt.Errorf("Error when setting flag: %s", err.Error())
 	}
-	fmt.Printf("%v removed!\n", args[0])
+	fmt.Printf("%v removed!\n", f.Arg(0))
 	return nil
 }
 func (Rm) Help() {
-	fmt.Println(`usage: flagship feature rm [featureName]
-	Removes a feature flag.`)
+	fmt.Println(`usage: flagship feature rm [featureName] [--yes]
+	Removes a feature flag.
+	If other features or throttles depend on it, confirmation is asked for unless --yes is given.`)
 }
@@ -145,6 +145,7 @@ func TestRmRun(t *testing.T) {
 				t.Fatal(err)
 			}
 			delete(res, "_pk")
+			delete(res, "version")
 			if diff := cmp.Diff(tt.expectedFeatures, res); diff != "" {
 				t.Error(diff)
 			}
@@ -0,0 +1,74 @@
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Schedule struct {
+	Store dynamostore.DynamoStore
+}
+
+func (s Schedule) Run(args []string) error {
+	f := config.CommandFlags("schedule")
+	on := f.String("on", "", "RFC3339 time from which the feature is enabled")
+	off := f.String("off", "", "RFC3339 time from which the feature is disabled")
+	if err := f.Parse(args); err != nil {
+		s.Help()
+		return err
+	}
+	if f.NArg() < 1 {
+		s.Help()
+		return errors.New("No featureName provided.")
+	}
+	if *on == "" && *off == "" {
+		s.Help()
+		return errors.New("At least one of --on or --off must be provided.")
+	}
+	enableAt, err := parseTime(*on)
+	if err != nil {
+		return fmt.Errorf("Invalid --on time: %s", err.Error())
+	}
+	disableAt, err := parseTime(*off)
+	if err != nil {
+		return fmt.Errorf("Invalid --off time: %s", err.Error())
+	}
+	if enableAt != nil && disableAt != nil && !enableAt.Before(*disableAt) {
+		return errors.New("--on must be before --off.")
+	}
+	err = s.Store.SetFeatureSchedule(context.Background(), f.Arg(0), enableAt, disableAt)
+	if err != nil {
+		return fmt.Errorf("Error when setting schedule: %s", err.Error())
+	}
+	fmt.Printf("%v: on %v, off %v\n", f.Arg(0), formatTime(enableAt), formatTime(disableAt))
+	return nil
+}
+
+func (Schedule) Help() {
+	fmt.Println(`usage: flagship feature schedule [featureName] --on <time> --off <time>
+	Enables a feature flag only between the given RFC3339 times, e.g. 2022-11-25T00:00:00Z.
+	Either bound can be omitted, in which case it is removed.`)
+}
+
+func parseTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "(none)"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,183 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestScheduleRun(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		features         any
+		expectError      bool
+		expectedFeatures any
+	}{
+		{
+			name: "no args",
+			features: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "no times",
+			args: []string{"aFeature"},
+			features: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid time",
+			args: []string{"aFeature", "--on", "tomorrow"},
+			features: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "on after off",
+			args: []string{"aFeature", "--on", "2022-11-29T00:00:00Z", "--off", "2022-11-25T00:00:00Z"},
+			features: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "no existing features",
+			args: []string{"aFeature", "--on", "2022-11-25T00:00:00Z", "--off", "2022-11-29T00:00:00Z"},
+			features: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"enableAt":  "2022-11-25T00:00:00Z",
+						"disableAt": "2022-11-29T00:00:00Z",
+					},
+				},
+			},
+		},
+		{
+			name: "feature false",
+			args: []string{"aFeature", "--off", "2022-11-29T00:00:00Z"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"disableAt": "2022-11-29T00:00:00Z",
+					},
+				},
+			},
+		},
+		{
+			name: "existing schedule is replaced",
+			args: []string{"aFeature", "--off", "2022-12-01T00:00:00Z"},
+			features: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"enableAt":  "2022-11-25T00:00:00Z",
+						"disableAt": "2022-11-29T00:00:00Z",
+					},
+					"bFeature": map[string]any{
+						"enableAt": "2022-11-25T00:00:00Z",
+					},
+				},
+			},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"disableAt": "2022-12-01T00:00:00Z",
+					},
+					"bFeature": map[string]any{
+						"enableAt": "2022-11-25T00:00:00Z",
+					},
+				},
+			},
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := Schedule{Store: store}
+			if tt.features != nil {
+				f, err := attributevalue.MarshalMap(tt.features)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Schedule{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Schedule{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedFeatures, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package feature
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Set sets a feature to a typed value.
+type Set struct {
+	Store dynamostore.DynamoStore
+	// In is read for confirmation when other flags depend on the feature.
+	In io.Reader
+}
+
+func (s Set) Run(args []string) error {
+	f := config.CommandFlags("set")
+	typ := f.String("type", "bool", "Type of the value: bool, string, number or json")
+	yes := f.BoolP("yes", "y", false, "Set without asking for confirmation when other flags depend on the feature")
+	if err := f.Parse(args); err != nil {
+		s.Help()
+		return err
+	}
+	if f.NArg() < 2 {
+		s.Help()
+		return errors.New("A featureName and value must be provided.")
+	}
+	v, err := ParseValue(*typ, f.Arg(1))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if err := dependents.Confirm(ctx, s.In, os.Stderr, s.Store, *yes, f.Arg(0)); err != nil {
+		return err
+	}
+	err = s.Store.SetFeatureValue(ctx, f.Arg(0), v)
+	if err != nil {
+		return fmt.Errorf("Error when setting flag: %s", err.Error())
+	}
+	fmt.Println(FormatValue(f.Arg(0), v, false))
+	return nil
+}
+
+func (Set) Help() {
+	fmt.Println(`usage: flagship feature set [featureName] [value] [--type bool|string|number|json] [--yes]
+	Sets a feature to a value of the given type, bool by default.
+	If other features or throttles depend on it, confirmation is asked for unless --yes is given.
+	JSON values can be objects or arrays, e.g. flagship feature set limits '{"daily": 10}' --type json`)
+}
+
+// ParseValue parses a feature value of type bool, string, number or json.
+func ParseValue(typ, s string) (interface{}, error) {
+	switch typ {
+	case "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid bool %q.", s)
+		}
+		return b, nil
+	case "string":
+		return s, nil
+	case "number":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("Invalid number %q.", s)
+		}
+		return n, nil
+	case "json":
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("Invalid JSON: %s", err.Error())
+		}
+		if v == nil {
+			return nil, errors.New("Feature values cannot be null.")
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("Unknown type %q, expected bool, string, number or json.", typ)
+}
+
+// FormatValue formats a feature and its value for printing.
+// Values other than booleans are shown with their type, and JSON values are indented if indent is true.
+func FormatValue(name string, v interface{}, indent bool) string {
+	switch v := v.(type) {
+	case bool:
+		return fmt.Sprintf("%s: %v", name, v)
+	case string:
+		return fmt.Sprintf("%s (string): %q", name, v)
+	case float64:
+		return fmt.Sprintf("%s (number): %s", name, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	var b []byte
+	var err error
+	if indent {
+		b, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		b, err = json.Marshal(v)
+	}
+	if err != nil {
+		return fmt.Sprintf("%s: %v", name, v)
+	}
+	return fmt.Sprintf("%s (json): %s", name, b)
+}
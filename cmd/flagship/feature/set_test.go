@@ -0,0 +1,141 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestSetRun(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectError      bool
+		expectedFeatures any
+	}{
+		{
+			name: "no args",
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false},
+			},
+			expectError: true,
+		},
+		{
+			name: "bool by default",
+			args: []string{"aFeature", "true"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": true},
+				"version":  float64(1),
+			},
+		},
+		{
+			name: "invalid bool",
+			args: []string{"aFeature", "yes please"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false},
+			},
+			expectError: true,
+		},
+		{
+			name: "string",
+			args: []string{"aFeature", "Welcome", "--type", "string"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": "Welcome"},
+				"version":  float64(1),
+			},
+		},
+		{
+			name: "number",
+			args: []string{"bFeature", "2.5", "--type", "number"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false, "bFeature": 2.5},
+				"version":  float64(1),
+			},
+		},
+		{
+			name: "invalid number",
+			args: []string{"aFeature", "ten", "--type", "number"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false},
+			},
+			expectError: true,
+		},
+		{
+			name: "json",
+			args: []string{"aFeature", `{"daily": 10, "regions": ["eu"]}`, "--type", "json"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": map[string]any{"daily": 10.0, "regions": []any{"eu"}}},
+				"version":  float64(1),
+			},
+		},
+		{
+			name: "json null",
+			args: []string{"aFeature", "null", "--type", "json"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false},
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown type",
+			args: []string{"aFeature", "1", "--type", "int"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{"aFeature": false},
+			},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := Set{Store: store}
+			f, err := attributevalue.MarshalMap(map[string]any{
+				"features": map[string]any{"aFeature": false},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Set{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Set{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			if diff := cmp.Diff(tt.expectedFeatures, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package freezecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Command struct {
+	Store dynamostore.DynamoStore
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("freeze")
+	reason := f.String("reason", "", "Why the record is being frozen")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	switch f.Arg(0) {
+	case "on":
+		if *reason == "" {
+			c.Help()
+			return errors.New("No reason provided.")
+		}
+		if err := c.Store.Freeze(context.Background(), *reason, time.Now().UTC()); err != nil {
+			return fmt.Errorf("Error when freezing: %s", err.Error())
+		}
+		fmt.Printf("%s frozen: %s\n", c.Store.Record, *reason)
+	case "off":
+		if err := c.Store.Unfreeze(context.Background()); err != nil {
+			return fmt.Errorf("Error when unfreezing: %s", err.Error())
+		}
+		fmt.Printf("%s unfrozen\n", c.Store.Record)
+	default:
+		c.Help()
+		return errors.New("Expected on or off.")
+	}
+	return nil
+}
+
+func (Command) Help() {
+	fmt.Println(`usage: flagship freeze on --reason <reason>
+       flagship freeze off
+	Freezes the record during an incident. While frozen, writes are refused unless --break-glass <reason> is passed.`)
+}
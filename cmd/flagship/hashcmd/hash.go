@@ -3,26 +3,59 @@ package hashcmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
-type Command struct{}
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
 
-func (Command) Help() {
-	fmt.Println(`
-	usage: flagship hash <throttle> <hash input>
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `
+	usage: flagship hash <throttle> <hash input> [--seed <seed>] [--algorithm fnv32a|murmur3|sha1] [--buckets <n>]
 	Returns the calculated hash value given an input and a throttle.
+	The seed, hash algorithm and buckets of the throttle are used, --seed, --algorithm and --buckets override them.
+	Throttles that do not exist yet are hashed with the defaults.
 	Useful for constructing whitelists.
 	`[1:])
 }
 func (c Command) Run(args []string) error {
-	if len(args) != 2 {
+	f := config.CommandFlags("hash")
+	seed := f.String("seed", "", "Override the seed of the throttle")
+	algorithm := f.String("algorithm", "", "Override the hash algorithm of the throttle: fnv32a, murmur3 or sha1")
+	buckets := f.Uint("buckets", 0, "Override the number of buckets of the throttle")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if f.NArg() != 2 {
 		c.Help()
 		return nil
 	}
-	fmt.Printf("Calculated Hash: %v", flagship.GetHash(context.Background(), args[0], strings.NewReader(args[1])))
-	fmt.Println()
+	_, throttles, err := c.Store.Load(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error loading throttles: %s", err.Error())
+	}
+	t := throttles[f.Arg(0)]
+	if f.Changed("seed") {
+		t.Seed = *seed
+	}
+	if f.Changed("algorithm") {
+		t.HashAlgorithm = *algorithm
+	}
+	if f.Changed("buckets") {
+		t.Buckets = *buckets
+	}
+	h, err := flagship.GetHashWithAlgorithm(context.Background(), t.HashAlgorithm, t.HashSeed(f.Arg(0)), t.BucketCount(), strings.NewReader(f.Arg(1)))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.Out, "Calculated Hash: %v\n", h)
 	return nil
 }
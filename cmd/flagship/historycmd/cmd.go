@@ -0,0 +1,88 @@
+package historycmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command lists the audit log of the record, optionally for a single flag.
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("history")
+	limit := f.Int("limit", 20, "The maximum number of changes to list, 0 for all")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	flag := f.Arg(0)
+	entries, err := c.Store.History(context.Background(), flag, *limit)
+	if err != nil {
+		return fmt.Errorf("Error loading history: %s", err.Error())
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(c.Out, "No history.")
+		return nil
+	}
+	w := tabwriter.NewWriter(c.Out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAT\tACTOR\tCHANGE\tREASON")
+	for _, e := range entries {
+		reason := e.Reason
+		if e.BreakGlass != "" {
+			reason = strings.TrimSpace(reason + " (break glass: " + e.BreakGlass + ")")
+		}
+		changes := make([]string, 0, len(e.Changes))
+		for _, ch := range e.Changes {
+			if flag != "" && ch.Name != flag {
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("%s %s: %s -> %s", ch.Type, ch.Name, format(ch.Old), format(ch.New)))
+		}
+		if len(changes) == 0 {
+			changes = append(changes, "(no change)")
+		}
+		for i, ch := range changes {
+			if i == 0 {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", e.Version, e.At.Format(time.RFC3339), e.Actor, ch, reason)
+				continue
+			}
+			fmt.Fprintf(w, "\t\t\t%s\t\n", ch)
+		}
+	}
+	return w.Flush()
+}
+
+// format returns a value of a change as compact JSON, showing only the value of features without a config.
+func format(v interface{}) string {
+	if v == nil {
+		return "(none)"
+	}
+	if m, ok := v.(map[string]interface{}); ok && len(m) == 1 {
+		if fv, ok := m["value"]; ok {
+			v = fv
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship history [flagName] [--limit <n>]
+	Lists changes to the record from the audit log, newest first.
+	Each write records who made it, when, what changed and the --reason given.
+	If a flagName is given only changes to that feature, throttle or control are listed.`)
+}
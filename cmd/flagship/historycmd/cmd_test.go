@@ -0,0 +1,121 @@
+package historycmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	record := uuid.NewString()
+	f, err := attributevalue.MarshalMap(map[string]any{
+		"features": map[string]any{
+			"newcheckout": false,
+		},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 10},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f["_pk"] = &types.AttributeValueMemberS{Value: record}
+	_, err = dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      f,
+		TableName: &name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+	store.Actor, store.Reason = "alice", "launch"
+	if err := store.SetFeature(ctx, "newcheckout", true); err != nil {
+		t.Fatal(err)
+	}
+	store.Actor, store.Reason = "bob", ""
+	if err := store.SetThrottleProbability(ctx, "search", 20); err != nil {
+		t.Fatal(err)
+	}
+	store.Reason = "incident"
+	if err := store.SetKillSwitch(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name          string
+		args          []string
+		expectedLines []string
+	}{
+		{
+			name: "all changes",
+			expectedLines: []string{
+				"VERSION AT ACTOR CHANGE REASON",
+				"3 <at> bob control killSwitch: (none) -> true incident",
+				`2 <at> bob throttle search: {"probability":10} -> {"probability":20}`,
+				"1 <at> alice feature newcheckout: false -> true launch",
+			},
+		},
+		{
+			name: "a single flag",
+			args: []string{"newcheckout"},
+			expectedLines: []string{
+				"VERSION AT ACTOR CHANGE REASON",
+				"1 <at> alice feature newcheckout: false -> true launch",
+			},
+		},
+		{
+			name: "limit",
+			args: []string{"--limit", "1"},
+			expectedLines: []string{
+				"VERSION AT ACTOR CHANGE REASON",
+				"3 <at> bob control killSwitch: (none) -> true incident",
+			},
+		},
+		{
+			name:          "unchanged flag",
+			args:          []string{"other"},
+			expectedLines: []string{"No history."},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			c := Command{Store: store, Out: &out}
+			if err := c.Run(tt.args); err != nil {
+				t.Fatalf("Command{}.Run(...) = %v", err)
+			}
+			var lines []string
+			for _, l := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+				fields := strings.Fields(l)
+				if len(fields) > 1 && fields[0] != "VERSION" {
+					fields[1] = "<at>"
+				}
+				lines = append(lines, strings.Join(fields, " "))
+			}
+			if diff := cmp.Diff(tt.expectedLines, lines); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+	t.Run("entries hold the document after the change", func(t *testing.T) {
+		e, err := store.LoadAuditEntry(ctx, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Document == nil || !e.Document.KillSwitch || e.Document.Version != 3 || e.Document.Throttles["search"].Probability != 20 {
+			t.Errorf("unexpected document %+v", e.Document)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package initcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command creates the table and an empty feature document, skipping whichever already exists.
+type Command struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("init")
+	wait := f.Duration("wait", 5*time.Minute, "Maximum time to wait for a new table to become active")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	ctx := context.Background()
+	err := c.Store.CreateTable(ctx, *wait)
+	switch {
+	case errors.Is(err, dynamostore.ErrTableExists):
+		t, err := c.Store.DescribeTable(ctx)
+		if err != nil {
+			return fmt.Errorf("Error describing table: %s", err.Error())
+		}
+		if err := dynamostore.CheckKeySchema(t); err != nil {
+			return fmt.Errorf("Table %s already exists and cannot be used: %s", c.Store.TableName, err.Error())
+		}
+		fmt.Fprintf(c.Out, "Table %s already exists.\n", c.Store.TableName)
+	case err != nil:
+		return fmt.Errorf("Error creating table: %s", err.Error())
+	default:
+		fmt.Fprintf(c.Out, "Created table %s.\n", c.Store.TableName)
+	}
+	store := c.Store
+	if store.Reason == "" {
+		store.Reason = "init"
+	}
+	err = store.InitRecord(ctx)
+	switch {
+	case errors.Is(err, dynamostore.ErrRecordExists):
+		fmt.Fprintf(c.Out, "Record %s already exists.\n", c.Store.Record)
+	case err != nil:
+		return fmt.Errorf("Error creating record: %s", err.Error())
+	default:
+		fmt.Fprintf(c.Out, "Created record %s.\n", c.Store.Record)
+	}
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship init [--wait 5m]
+	Creates the table, with the _pk string hash key and billed per request, and an empty feature document in the record.
+	Either is left as it is if it already exists, so init is safe to run again.
+	Use --tableName, --recordName and --env to choose what to create.`)
+}
@@ -0,0 +1,127 @@
+package initcmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	existing, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	wrongKey := dynamotesting.TableName()
+	_, err := dclient.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+		TableName:            &wrongKey,
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}},
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dclient.DeleteTable(context.Background(), &dynamodb.DeleteTableInput{TableName: &wrongKey})
+	empty := map[string]any{
+		"features":  map[string]any{},
+		"throttles": map[string]any{},
+		"version":   float64(1),
+	}
+	tests := []struct {
+		name        string
+		table       string
+		document    any
+		expectError bool
+		expectedOut string
+		expectedDoc any
+	}{
+		{
+			name:        "new table",
+			expectedOut: "Created table {table}.\nCreated record {record}.\n",
+			expectedDoc: empty,
+		},
+		{
+			name:        "existing table",
+			table:       existing,
+			expectedOut: "Table {table} already exists.\nCreated record {record}.\n",
+			expectedDoc: empty,
+		},
+		{
+			name:        "existing record",
+			table:       existing,
+			document:    map[string]any{"features": map[string]any{"aFeature": true}},
+			expectedOut: "Table {table} already exists.\nRecord {record} already exists.\n",
+			expectedDoc: map[string]any{"features": map[string]any{"aFeature": true}},
+		},
+		{
+			name:        "table with the wrong key",
+			table:       wrongKey,
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			record := uuid.NewString()
+			table := tt.table
+			if table == "" {
+				table = dynamotesting.TableName()
+				defer dclient.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: &table})
+			}
+			if tt.document != nil {
+				f, err := attributevalue.MarshalMap(tt.document)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(ctx, &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &table,
+				})
+			}
+			out := new(bytes.Buffer)
+			c := Command{Store: dynamostore.NewDynamoStoreWithClient(table, record, dclient), Out: out}
+			err := c.Run([]string{"--wait", "30s"})
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Command{}.Run(...) = nil")
+				}
+				return
+			}
+			expectedOut := strings.NewReplacer("{table}", table, "{record}", record).Replace(tt.expectedOut)
+			if diff := cmp.Diff(expectedOut, out.String()); diff != "" {
+				t.Error(diff)
+			}
+			i, err := dclient.GetItem(ctx, &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &table,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			if diff := cmp.Diff(tt.expectedDoc, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
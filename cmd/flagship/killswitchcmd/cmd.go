@@ -0,0 +1,31 @@
+package killswitchcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Command struct {
+	Store dynamostore.DynamoStore
+}
+
+func (c Command) Run(args []string) error {
+	if len(args) < 1 || (args[0] != "on" && args[0] != "off") {
+		c.Help()
+		return errors.New("Expected on or off.")
+	}
+	on := args[0] == "on"
+	if err := c.Store.SetKillSwitch(context.Background(), on); err != nil {
+		return fmt.Errorf("Error when setting kill switch: %s", err.Error())
+	}
+	fmt.Printf("Kill switch: %s\n", args[0])
+	return nil
+}
+
+func (Command) Help() {
+	fmt.Println(`usage: flagship killswitch on|off
+	When on, every feature and throttle falls back to the safe defaults registered by each service.`)
+}
@@ -4,38 +4,96 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/feature"
+	"github.com/joerdav/flagship/cmd/flagship/metacmd"
 	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/models"
 )
 
 type Command struct{}
 
-func (Command) Run(args []string) error {
+func (c Command) Run(args []string) error {
 	f := config.GlobalFlags()
 	f.Parse(args)
+	lf := config.CommandFlags("ls")
+	tag := lf.String("tag", "", "Only list flags with this tag")
+	owner := lf.String("owner", "", "Only list flags owned by this team")
+	expired := lf.Bool("expired", false, "Only list temporary flags that are past their expiry")
+	if err := lf.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	match := func(m *models.Metadata) bool {
+		if *tag == "" && *owner == "" && !*expired {
+			return true
+		}
+		if m == nil {
+			return false
+		}
+		return (*tag == "" || m.HasTag(*tag)) && (*owner == "" || m.Owner == *owner) && (!*expired || m.Expired(time.Now()))
+	}
 	region := os.Getenv("AWS_REGION")
-	store, err := dynamostore.NewDynamoStore(f.TableName, f.RecordName, region)
+	store, err := dynamostore.NewDynamoStore(f.TableName, f.Record(), region)
 	if err != nil {
 		return fmt.Errorf("Error when creating DynamoDB connection: %s", err.Error())
 	}
-	features, throttles, err := store.Load(context.Background())
+	doc, err := store.LoadDocument(context.Background())
 	if err != nil {
 		return fmt.Errorf("Error when loading document: %s", err.Error())
 	}
+	fmt.Printf("Version: %d\n", doc.Version)
+	if doc.KillSwitch {
+		fmt.Println("Kill switch: ON")
+	}
+	if doc.Freeze != nil {
+		fmt.Printf("Frozen since %s: %s\n", doc.Freeze.At.Format(time.RFC3339), doc.Freeze.Reason)
+	}
 	fmt.Println("Features:")
-	for f, v := range features {
-		b, ok := v.(bool)
-		if !ok {
-			fmt.Printf("	%s: (not a boolean)]\n", f)
+	for _, f := range sortedKeys(doc.Features) {
+		fc := doc.FeatureConfigs[f]
+		if !match(fc.Metadata) {
 			continue
 		}
-		fmt.Printf("	%s: %v\n", f, b)
+		fmt.Printf("	%s%s\n", feature.FormatValue(f, doc.Features[f], false), schedule(fc.EnableAt, fc.DisableAt))
+		if fc.Metadata != nil {
+			metacmd.Print(os.Stdout, "		", *fc.Metadata)
+		}
 	}
 	fmt.Println("Throttles:")
-	for f, v := range throttles {
-		fmt.Printf("	%s:\n", f)
+	for _, f := range sortedKeys(doc.Throttles) {
+		v := doc.Throttles[f]
+		if !match(v.Metadata) {
+			continue
+		}
+		fmt.Printf("	%s:%s\n", f, schedule(v.EnableAt, v.DisableAt))
+		if v.Metadata != nil {
+			metacmd.Print(os.Stdout, "		", *v.Metadata)
+		}
 		fmt.Printf("		Probability: %v\n", v.Probability)
+		if v.Ramp != nil {
+			fmt.Printf("		Current Probability: %v\n", v.EffectiveProbability(time.Now()))
+			fmt.Printf("		Ramp (linear: %v):\n", v.Ramp.Linear)
+			for _, s := range v.Ramp.Steps {
+				fmt.Printf("			%s: %v\n", s.At.Format(time.RFC3339), s.Probability)
+			}
+		}
+		if len(v.Allow) > 0 {
+			fmt.Printf("		Allow: [ %s ]\n", strings.Join(v.Allow, ", "))
+		}
+		if len(v.Deny) > 0 {
+			fmt.Printf("		Deny: [ %s ]\n", strings.Join(v.Deny, ", "))
+		}
+		if len(v.AllowSegments) > 0 {
+			fmt.Printf("		Allow Segments: [ %s ]\n", strings.Join(v.AllowSegments, ", "))
+		}
+		if len(v.DenySegments) > 0 {
+			fmt.Printf("		Deny Segments: [ %s ]\n", strings.Join(v.DenySegments, ", "))
+		}
 		fmt.Print("		Whitelist: [ ")
 		for i, w := range v.Whitelist {
 			fmt.Print(w)
@@ -49,7 +107,31 @@ func (Command) Run(args []string) error {
 	return nil
 }
 
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schedule(enableAt, disableAt *time.Time) string {
+	var s string
+	if enableAt != nil {
+		s += " from " + enableAt.Format(time.RFC3339)
+	}
+	if disableAt != nil {
+		s += " until " + disableAt.Format(time.RFC3339)
+	}
+	if s == "" {
+		return ""
+	}
+	return " (scheduled" + s + ")"
+}
+
 func (Command) Help() {
-	fmt.Println(`usage: flagship ls
-	Returns the status of all feature flags.`)
+	fmt.Println(`usage: flagship ls [--tag <tag>] [--owner <team>] [--expired]
+	Returns the status of all feature flags, with their metadata.
+	When filters are given only flags whose metadata matches all of them are listed.`)
 }
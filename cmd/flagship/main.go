@@ -7,10 +7,26 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/joerdav/flagship/cmd/flagship/applycmd"
 	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/diffcmd"
+	"github.com/joerdav/flagship/cmd/flagship/doctorcmd"
+	"github.com/joerdav/flagship/cmd/flagship/envcmd"
+	"github.com/joerdav/flagship/cmd/flagship/evalcmd"
+	"github.com/joerdav/flagship/cmd/flagship/exportcmd"
 	"github.com/joerdav/flagship/cmd/flagship/feature"
+	"github.com/joerdav/flagship/cmd/flagship/freezecmd"
 	"github.com/joerdav/flagship/cmd/flagship/hashcmd"
+	"github.com/joerdav/flagship/cmd/flagship/historycmd"
+	"github.com/joerdav/flagship/cmd/flagship/initcmd"
+	"github.com/joerdav/flagship/cmd/flagship/killswitchcmd"
 	"github.com/joerdav/flagship/cmd/flagship/lscmd"
+	"github.com/joerdav/flagship/cmd/flagship/metacmd"
+	"github.com/joerdav/flagship/cmd/flagship/promotecmd"
+	"github.com/joerdav/flagship/cmd/flagship/rollbackcmd"
+	"github.com/joerdav/flagship/cmd/flagship/segmentcmd"
+	"github.com/joerdav/flagship/cmd/flagship/throttle"
+	"github.com/joerdav/flagship/cmd/flagship/validatecmd"
 	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
@@ -25,8 +41,16 @@ func goInstallVersion() string {
 }
 
 func main() {
-	if err := run(); err != nil {
+	err := run()
+	if errors.Is(err, diffcmd.ErrDiffer) {
+		os.Exit(1)
+	}
+	if err != nil {
 		fmt.Printf("An error occured: %v\n", err)
+		// Like diff(1), diff exits with 1 when the documents differ and 2 when they cannot be compared.
+		if len(os.Args) > 1 && os.Args[1] == "diff" {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
@@ -51,9 +75,6 @@ func (pc parentCommand) Run(args []string) error {
 		pc.Help()
 		return errors.New("subcommand not found")
 	}
-	if len(args) == 1 {
-		cmd.Run([]string{})
-	}
 	return cmd.Run(args[1:])
 }
 
@@ -73,19 +94,78 @@ func newParentCommand(name string, cmds map[string]command) parentCommand {
 func run() error {
 	f := config.GlobalFlags()
 	f.Parse(os.Args[1:])
+	if err := dynamostore.ValidateEnvironment(f.Env); err != nil {
+		return err
+	}
 	region := os.Getenv("AWS_REGION")
-	store, err := dynamostore.NewDynamoStore(f.TableName, f.RecordName, region)
+	store, err := dynamostore.NewDynamoStore(f.TableName, f.Record(), region)
 	if err != nil {
 		return fmt.Errorf("Error when creating DynamoDB connection: %s", err.Error())
 	}
+	store.BreakGlass = f.BreakGlass
+	store.ExpectVersion = f.ExpectedVersion()
+	store.Actor, store.Reason = f.Actor, f.Reason
+	store.Identify = config.CallerIdentity(region)
+	if store.BreakGlass != "" {
+		fmt.Fprintf(os.Stderr, "Breaking glass: %s\n", store.BreakGlass)
+	}
 	cmds := map[string]command{
-		"ls":   lscmd.Command{},
-		"hash": hashcmd.Command{},
+		"ls":         lscmd.Command{},
+		"hash":       hashcmd.Command{Store: store, Out: os.Stdout},
+		"freeze":     freezecmd.Command{Store: store},
+		"promote":    promotecmd.Command{Store: store, Record: f.RecordName, In: os.Stdin, Out: os.Stdout},
+		"killswitch": killswitchcmd.Command{Store: store},
+		"validate":   validatecmd.Command{Store: store, In: os.Stdin, Out: os.Stdout},
+		"history":    historycmd.Command{Store: store, Out: os.Stdout},
+		"eval":       evalcmd.Command{Store: store, Out: os.Stdout},
+		"export":     exportcmd.Command{Store: store, Out: os.Stdout},
+		"diff":       diffcmd.Command{Store: store, Record: f.RecordName, In: os.Stdin, Out: os.Stdout},
+		"apply":      applycmd.Command{Store: store, In: os.Stdin, Out: os.Stdout},
+		"init":       initcmd.Command{Store: store, Out: os.Stdout},
+		"doctor":     doctorcmd.Command{Store: store, Out: os.Stdout},
+		"rollback":   rollbackcmd.Command{Store: store, In: os.Stdin, Out: os.Stdout},
 		"feature": newParentCommand("sub", map[string]command{
-			"get":     feature.Get{Store: store, Out: os.Stdout},
-			"enable":  feature.Enable{Store: store},
-			"disable": feature.Disable{Store: store},
-			"rm":      feature.Rm{Store: store},
+			"get":      feature.Get{Store: store, Out: os.Stdout},
+			"set":      feature.Set{Store: store, In: os.Stdin},
+			"enable":   feature.Enable{Store: store, In: os.Stdin},
+			"disable":  feature.Disable{Store: store, In: os.Stdin},
+			"rm":       feature.Rm{Store: store, In: os.Stdin},
+			"schedule": feature.Schedule{Store: store},
+		}),
+		"throttle": newParentCommand("throttle", map[string]command{
+			"get":  throttle.Get{Store: store, Out: os.Stdout},
+			"set":  throttle.Set{Store: store},
+			"rm":   throttle.Rm{Store: store},
+			"ramp": throttle.Ramp{Store: store},
+			"whitelist": newParentCommand("throttle whitelist", map[string]command{
+				"add": throttle.WhitelistAdd{Store: store},
+				"rm":  throttle.WhitelistRm{Store: store},
+			}),
+			"force-reject": newParentCommand("throttle force-reject", map[string]command{
+				"on":  throttle.ForceReject{Store: store, Value: true},
+				"off": throttle.ForceReject{Store: store},
+			}),
+			"allow": newParentCommand("throttle allow", map[string]command{
+				"add": throttle.ListAdd{Store: store, List: "allow"},
+				"rm":  throttle.ListRm{Store: store, List: "allow"},
+			}),
+			"deny": newParentCommand("throttle deny", map[string]command{
+				"add": throttle.ListAdd{Store: store, List: "deny"},
+				"rm":  throttle.ListRm{Store: store, List: "deny"},
+			}),
+		}),
+		"meta": newParentCommand("meta", map[string]command{
+			"get": metacmd.Get{Store: store, Out: os.Stdout},
+			"set": metacmd.Set{Store: store, Out: os.Stdout},
+		}),
+		"segment": newParentCommand("segment", map[string]command{
+			"put": segmentcmd.Put{Store: store, In: os.Stdin, Out: os.Stdout},
+			"rm":  segmentcmd.Rm{Store: store, Out: os.Stdout},
+			"get": segmentcmd.Get{Store: store, Out: os.Stdout},
+		}),
+		"env": newParentCommand("env", map[string]command{
+			"ls":      envcmd.Ls{Store: store, Record: f.RecordName, Out: os.Stdout},
+			"compare": envcmd.Compare{Store: store, Record: f.RecordName, Out: os.Stdout},
 		}),
 	}
 	cmdl := []string{}
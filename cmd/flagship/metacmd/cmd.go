@@ -0,0 +1,162 @@
+package metacmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// Set modifies the metadata of a feature, or if there is none a throttle, with the same name.
+type Set struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+	// Now is used as the creation date of flags that do not have one. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func (s Set) Run(args []string) error {
+	f := config.CommandFlags("set")
+	description := f.String("description", "", "What the flag is for")
+	owner := f.String("owner", "", "The team that owns the flag")
+	tags := f.StringSlice("tags", nil, "Comma separated tags, replacing any existing tags")
+	expires := f.String("expires", "", "RFC3339 time by which the flag is intended to be removed, empty to remove")
+	permanent := f.Bool("permanent", false, "Whether the flag is permanent rather than temporary")
+	if err := f.Parse(args); err != nil {
+		s.Help()
+		return err
+	}
+	if f.NArg() < 1 {
+		s.Help()
+		return errors.New("No flagName provided.")
+	}
+	if s.Now == nil {
+		s.Now = time.Now
+	}
+	ctx := context.Background()
+	name := f.Arg(0)
+	throttle, m, err := load(ctx, s.Store, name)
+	if err != nil {
+		return err
+	}
+	if f.Changed("description") {
+		m.Description = *description
+	}
+	if f.Changed("owner") {
+		m.Owner = *owner
+	}
+	if f.Changed("tags") {
+		m.Tags = *tags
+	}
+	if f.Changed("expires") {
+		m.ExpiresAt = nil
+		if *expires != "" {
+			t, err := time.Parse(time.RFC3339, *expires)
+			if err != nil {
+				return fmt.Errorf("Invalid --expires time: %s", err.Error())
+			}
+			m.ExpiresAt = &t
+		}
+	}
+	if f.Changed("permanent") {
+		m.Permanent = *permanent
+	}
+	if m.CreatedAt == nil {
+		now := s.Now().UTC().Truncate(time.Second)
+		m.CreatedAt = &now
+	}
+	if throttle {
+		err = s.Store.SetThrottleMetadata(ctx, name, m)
+	} else {
+		err = s.Store.SetFeatureMetadata(ctx, name, m)
+	}
+	if err != nil {
+		return fmt.Errorf("Error when setting metadata: %s", err.Error())
+	}
+	Print(s.Out, "", m)
+	return nil
+}
+
+func (s Set) Help() {
+	fmt.Fprintln(s.Out, `usage: flagship meta set [flagName] --description <text> --owner <team> --tags a,b --expires <time> --permanent
+	Sets the metadata of a feature, or of a throttle if there is no feature with the name.
+	Only the given fields are changed. The creation date is set the first time metadata is set.`)
+}
+
+// Get prints the metadata of a feature, or if there is none a throttle, with the same name.
+type Get struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (g Get) Run(args []string) error {
+	if len(args) < 1 {
+		g.Help()
+		return errors.New("No flagName provided.")
+	}
+	_, m, err := load(context.Background(), g.Store, args[0])
+	if err != nil {
+		return err
+	}
+	Print(g.Out, "", m)
+	return nil
+}
+
+func (g Get) Help() {
+	fmt.Fprintln(g.Out, `usage: flagship meta get [flagName]
+	Prints the metadata of a feature, or of a throttle if there is no feature with the name.`)
+}
+
+// load returns whether name is a throttle, and its metadata.
+func load(ctx context.Context, store dynamostore.DynamoStore, name string) (bool, models.Metadata, error) {
+	doc, err := store.LoadDocument(ctx)
+	if err != nil {
+		return false, models.Metadata{}, fmt.Errorf("Error when loading document: %s", err.Error())
+	}
+	if _, ok := doc.Features[name]; ok {
+		if m := doc.FeatureConfigs[name].Metadata; m != nil {
+			return false, *m, nil
+		}
+		return false, models.Metadata{}, nil
+	}
+	if t, ok := doc.Throttles[name]; ok {
+		if t.Metadata != nil {
+			return true, *t.Metadata, nil
+		}
+		return true, models.Metadata{}, nil
+	}
+	return false, models.Metadata{}, fmt.Errorf("No feature or throttle found: %s", name)
+}
+
+// Print writes the fields of m that are set, one per line, each prefixed with indent.
+func Print(w io.Writer, indent string, m models.Metadata) {
+	if m.Description != "" {
+		fmt.Fprintf(w, "%sDescription: %s\n", indent, m.Description)
+	}
+	if m.Owner != "" {
+		fmt.Fprintf(w, "%sOwner: %s\n", indent, m.Owner)
+	}
+	if len(m.Tags) > 0 {
+		fmt.Fprintf(w, "%sTags: [ %s ]\n", indent, strings.Join(m.Tags, ", "))
+	}
+	if m.CreatedAt != nil {
+		fmt.Fprintf(w, "%sCreated: %s\n", indent, m.CreatedAt.Format(time.RFC3339))
+	}
+	lifetime := "temporary"
+	if m.Permanent {
+		lifetime = "permanent"
+	}
+	if m.ExpiresAt != nil {
+		lifetime += ", expires " + m.ExpiresAt.Format(time.RFC3339)
+		if m.Expired(time.Now()) {
+			lifetime += " (expired)"
+		}
+	}
+	fmt.Fprintf(w, "%sLifetime: %s\n", indent, lifetime)
+}
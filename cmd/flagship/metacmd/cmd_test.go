@@ -0,0 +1,165 @@
+package metacmd
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestSetRun(t *testing.T) {
+	now := time.Date(2022, 11, 25, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		args        []string
+		doc         any
+		expectError bool
+		expectedDoc any
+	}{
+		{
+			name: "no args",
+			doc: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedDoc: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "flag missing",
+			args: []string{"aFeature", "--owner", "payments"},
+			doc: map[string]any{
+				"features": map[string]any{},
+			},
+			expectedDoc: map[string]any{
+				"features": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "new feature metadata",
+			args: []string{"aFeature", "--owner", "payments", "--tags", "payments,checkout", "--expires", "2023-01-01T00:00:00Z"},
+			doc: map[string]any{
+				"features": map[string]any{"aFeature": true},
+			},
+			expectedDoc: map[string]any{
+				"features": map[string]any{"aFeature": true},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"metadata": map[string]any{
+							"owner":     "payments",
+							"tags":      []any{"payments", "checkout"},
+							"createdAt": "2022-11-25T00:00:00Z",
+							"expiresAt": "2023-01-01T00:00:00Z",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "existing feature metadata",
+			args: []string{"aFeature", "--description", "New checkout", "--permanent", "--expires", ""},
+			doc: map[string]any{
+				"features": map[string]any{"aFeature": true},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"enableAt": "2022-11-01T00:00:00Z",
+						"metadata": map[string]any{
+							"owner":     "payments",
+							"createdAt": "2022-11-01T00:00:00Z",
+							"expiresAt": "2023-01-01T00:00:00Z",
+						},
+					},
+				},
+			},
+			expectedDoc: map[string]any{
+				"features": map[string]any{"aFeature": true},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{
+						"enableAt": "2022-11-01T00:00:00Z",
+						"metadata": map[string]any{
+							"description": "New checkout",
+							"owner":       "payments",
+							"createdAt":   "2022-11-01T00:00:00Z",
+							"permanent":   true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "throttle metadata",
+			args: []string{"aThrottle", "--owner", "search"},
+			doc: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 10},
+				},
+			},
+			expectedDoc: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{
+						"probability": 10.0,
+						"metadata": map[string]any{
+							"owner":     "search",
+							"createdAt": "2022-11-25T00:00:00Z",
+						},
+					},
+				},
+			},
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := Set{Store: store, Out: io.Discard, Now: func() time.Time { return now }}
+			f, err := attributevalue.MarshalMap(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Set{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Set{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedDoc, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package promotecmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Command struct {
+	Store dynamostore.DynamoStore
+	// Record is the record name, without an environment.
+	Record string
+	In     io.Reader
+	Out    io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("promote")
+	from := f.String("from", "", "Environment to copy flags from, or record:<name> for another record")
+	to := f.String("to", "", "Environment to copy flags to, or record:<name> for another record")
+	yes := f.BoolP("yes", "y", false, "Apply without asking for confirmation")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	source, err := c.record(*from)
+	if err != nil {
+		return err
+	}
+	target := c.Store
+	target.Record, err = c.record(*to)
+	if err != nil {
+		return err
+	}
+	if source == target.Record {
+		c.Help()
+		return errors.New("--from and --to must be different records.")
+	}
+	ctx := context.Background()
+	p, err := target.PlanPromotion(ctx, source, f.Args())
+	if err != nil {
+		return fmt.Errorf("Error planning promotion: %s", err.Error())
+	}
+	if len(p.Changes) == 0 {
+		fmt.Fprintln(c.Out, "No changes.")
+		return nil
+	}
+	fmt.Fprintf(c.Out, "Promoting from %s to %s:\n", sideName(*from), sideName(*to))
+	for _, ch := range p.Changes {
+		fmt.Fprintf(c.Out, "	%s %s: %s -> %s\n", ch.Type, ch.Name, display(ch.Old), display(ch.New))
+	}
+	if err := dependents.WarnChanges(ctx, c.Out, target, p.Changes); err != nil {
+		return err
+	}
+	if !*yes {
+		fmt.Fprint(c.Out, "Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(c.In).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("Promotion cancelled.")
+		}
+	}
+	err = target.ApplyPromotion(ctx, p)
+	if err != nil {
+		return fmt.Errorf("Error applying promotion: %s", err.Error())
+	}
+	fmt.Fprintf(c.Out, "Promoted %d changes.\n", len(p.Changes))
+	return nil
+}
+
+// record returns the record of one side of the promotion.
+// A side is an environment of the record, or another record if it is prefixed with record:.
+// The env: prefix chooses an environment explicitly, env: alone is the default environment.
+func (c Command) record(side string) (string, error) {
+	if record := strings.TrimPrefix(side, "record:"); record != side {
+		return record, nil
+	}
+	env := strings.TrimPrefix(side, "env:")
+	if err := dynamostore.ValidateEnvironment(env); err != nil {
+		return "", err
+	}
+	return dynamostore.EnvironmentRecord(c.Record, env), nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship promote --from <env|record:name> --to <env|record:name> [flagName...]
+	Copies features and throttles from one environment to another, showing the changes and asking for confirmation first.
+	Prefix a side with record: to copy from or to another record, e.g. a record in an environment, record:features#prod.
+	If no flags are given, every feature and throttle is copied.
+	If the target is modified before the changes are applied, the promotion is aborted.
+	Features that other features or throttles depend on are warned about.
+	Use --yes to skip confirmation.`)
+}
+
+func display(v interface{}) string {
+	if v == nil {
+		return "(missing)"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func sideName(side string) string {
+	if record := strings.TrimPrefix(side, "record:"); record != side {
+		return record
+	}
+	if env := strings.TrimPrefix(side, "env:"); env != "" {
+		return env
+	}
+	return "(default)"
+}
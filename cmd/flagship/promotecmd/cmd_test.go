@@ -0,0 +1,202 @@
+package promotecmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+// editingReader simulates a concurrent edit to the target record while the user is being asked for confirmation.
+type editingReader struct {
+	edit func()
+	r    io.Reader
+}
+
+func (e *editingReader) Read(p []byte) (int, error) {
+	if e.edit != nil {
+		e.edit()
+		e.edit = nil
+	}
+	return e.r.Read(p)
+}
+
+func TestRun(t *testing.T) {
+	staging := map[string]any{
+		"features": map[string]any{
+			"aFeature": true,
+			"bFeature": true,
+		},
+		"featureConfigs": map[string]any{
+			"aFeature": map[string]any{"disableAt": "2022-11-29T00:00:00Z"},
+		},
+		"throttles": map[string]any{
+			"aThrottle": map[string]any{"probability": 50.0},
+		},
+	}
+	prod := map[string]any{
+		"features": map[string]any{
+			"aFeature": false,
+			"cFeature": true,
+		},
+	}
+	tests := []struct {
+		name             string
+		args             []string
+		input            string
+		concurrentEdit   bool
+		expectError      bool
+		expectedFeatures any
+	}{
+		{
+			name:             "same environment",
+			args:             []string{"--from", "staging", "--to", "staging"},
+			expectError:      true,
+			expectedFeatures: prod,
+		},
+		{
+			name:             "missing flag",
+			args:             []string{"--from", "staging", "--to", "prod", "--yes", "dFeature"},
+			expectError:      true,
+			expectedFeatures: prod,
+		},
+		{
+			name:             "cancelled",
+			args:             []string{"--from", "staging", "--to", "prod"},
+			input:            "n\n",
+			expectError:      true,
+			expectedFeatures: prod,
+		},
+		{
+			name:  "confirmed single flag",
+			args:  []string{"--from", "staging", "--to", "prod", "aFeature"},
+			input: "y\n",
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+					"cFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{"disableAt": "2022-11-29T00:00:00Z"},
+				},
+				"throttles": map[string]any{},
+			},
+		},
+		{
+			name:  "records",
+			args:  []string{"--from", "record:{record}#staging", "--to", "record:{record}#prod", "aFeature"},
+			input: "y\n",
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+					"cFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{"disableAt": "2022-11-29T00:00:00Z"},
+				},
+				"throttles": map[string]any{},
+			},
+		},
+		{
+			name: "all flags",
+			args: []string{"--from", "staging", "--to", "prod", "--yes"},
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": true,
+					"bFeature": true,
+					"cFeature": true,
+				},
+				"featureConfigs": map[string]any{
+					"aFeature": map[string]any{"disableAt": "2022-11-29T00:00:00Z"},
+				},
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 50.0},
+				},
+			},
+		},
+		{
+			name:           "concurrent edit aborts",
+			args:           []string{"--from", "staging", "--to", "prod", "aFeature"},
+			input:          "y\n",
+			concurrentEdit: true,
+			expectError:    true,
+			expectedFeatures: map[string]any{
+				"features": map[string]any{
+					"aFeature": false,
+					"cFeature": true,
+					"dFeature": true,
+				},
+			},
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			for env, doc := range map[string]any{"staging": staging, "prod": prod} {
+				f, err := attributevalue.MarshalMap(doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: dynamostore.EnvironmentRecord(record, env)}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			prodStore := store
+			prodStore.Record = dynamostore.EnvironmentRecord(record, "prod")
+			in := &editingReader{r: strings.NewReader(tt.input)}
+			if tt.concurrentEdit {
+				in.edit = func() {
+					if err := prodStore.SetFeature(context.Background(), "dFeature", true); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			c := Command{Store: store, Record: record, In: in, Out: new(bytes.Buffer)}
+			var args []string
+			for _, a := range tt.args {
+				args = append(args, strings.ReplaceAll(a, "{record}", record))
+			}
+			err := c.Run(args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: prodStore.Record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedFeatures, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
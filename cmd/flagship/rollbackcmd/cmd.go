@@ -0,0 +1,104 @@
+package rollbackcmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/dependents"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Command restores the record, or a single flag, to a previous state from the audit log.
+type Command struct {
+	Store dynamostore.DynamoStore
+	In    io.Reader
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("rollback")
+	toVersion := f.Int64("to-version", 0, "Version of the record to restore")
+	flag := f.String("flag", "", "Feature or throttle to restore")
+	steps := f.Int("steps", 1, "Number of changes to the --flag to undo")
+	yes := f.BoolP("yes", "y", false, "Apply without asking for confirmation")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if (*toVersion > 0) == (*flag != "") {
+		c.Help()
+		return errors.New("Exactly one of --to-version or --flag must be provided.")
+	}
+	ctx := context.Background()
+	var p *dynamostore.Promotion
+	var err error
+	var description string
+	if *toVersion > 0 {
+		p, err = c.Store.PlanRollback(ctx, *toVersion)
+		description = fmt.Sprintf("version %d", *toVersion)
+	} else {
+		p, err = c.Store.PlanFlagRollback(ctx, *flag, *steps)
+		description = fmt.Sprintf("%s before its last %d changes", *flag, *steps)
+		if *steps == 1 {
+			description = fmt.Sprintf("%s before its last change", *flag)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Error planning rollback: %s", err.Error())
+	}
+	if len(p.Changes) == 0 {
+		fmt.Fprintln(c.Out, "No changes.")
+		return nil
+	}
+	fmt.Fprintf(c.Out, "Rolling back to %s:\n", description)
+	for _, ch := range p.Changes {
+		fmt.Fprintf(c.Out, "	%s %s: %s -> %s\n", ch.Type, ch.Name, display(ch.Old), display(ch.New))
+	}
+	if err := dependents.WarnChanges(ctx, c.Out, c.Store, p.Changes); err != nil {
+		return err
+	}
+	if !*yes {
+		fmt.Fprint(c.Out, "Apply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(c.In).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("Rollback cancelled.")
+		}
+	}
+	store := c.Store
+	if store.Reason == "" {
+		store.Reason = "rollback to " + description
+	}
+	if err := store.ApplyPromotion(ctx, p); err != nil {
+		return fmt.Errorf("Error applying rollback: %s", err.Error())
+	}
+	fmt.Fprintf(c.Out, "Rolled back %d changes.\n", len(p.Changes))
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship rollback (--to-version <n> | --flag <flagName> [--steps <n>]) [--yes]
+	Restores features and throttles to a previous state from the audit log, showing the changes and asking for confirmation first.
+	--to-version restores every feature and throttle to how it was at that version, removing those added since.
+	--flag restores a single feature or throttle to how it was before its last --steps changes.
+	The kill switch and freeze are not rolled back.
+	If the record is modified before the changes are applied, the rollback is aborted.
+	Features that other features or throttles depend on are warned about.
+	Use --yes to skip confirmation.`)
+}
+
+func display(v interface{}) string {
+	if v == nil {
+		return "(missing)"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
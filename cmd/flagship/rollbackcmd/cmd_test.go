@@ -0,0 +1,186 @@
+package rollbackcmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+// editingReader simulates a concurrent edit to the record while the user is being asked for confirmation.
+type editingReader struct {
+	edit func()
+	r    io.Reader
+}
+
+func (e *editingReader) Read(p []byte) (int, error) {
+	if e.edit != nil {
+		e.edit()
+		e.edit = nil
+	}
+	return e.r.Read(p)
+}
+
+func TestRun(t *testing.T) {
+	doc := func(features map[string]any, probability float64) map[string]any {
+		return map[string]any{
+			"features":       features,
+			"featureConfigs": map[string]any{},
+			"throttles": map[string]any{
+				"search": map[string]any{"probability": probability},
+			},
+		}
+	}
+	latest := doc(map[string]any{"a": true, "b": true}, 30)
+	tests := []struct {
+		name           string
+		args           []string
+		input          string
+		concurrentEdit bool
+		expectError    bool
+		expectedDoc    any
+	}{
+		{
+			name:        "no args",
+			expectError: true,
+			expectedDoc: latest,
+		},
+		{
+			name:        "version and flag",
+			args:        []string{"--to-version", "1", "--flag", "a"},
+			expectError: true,
+			expectedDoc: latest,
+		},
+		{
+			name:        "to version",
+			args:        []string{"--to-version", "1", "--yes"},
+			expectedDoc: doc(map[string]any{"a": true}, 10),
+		},
+		{
+			name:        "to version removes later flags",
+			args:        []string{"--to-version", "2", "--yes"},
+			expectedDoc: doc(map[string]any{"a": true}, 20),
+		},
+		{
+			name:        "missing version",
+			args:        []string{"--to-version", "9", "--yes"},
+			expectError: true,
+			expectedDoc: latest,
+		},
+		{
+			name:        "flag",
+			args:        []string{"--flag", "search", "--yes"},
+			expectedDoc: doc(map[string]any{"a": true, "b": true}, 20),
+		},
+		{
+			name:        "flag steps",
+			args:        []string{"--flag", "search", "--steps", "2", "--yes"},
+			expectedDoc: doc(map[string]any{"a": true, "b": true}, 10),
+		},
+		{
+			name:        "flag that did not exist",
+			args:        []string{"--flag", "b", "--yes"},
+			expectedDoc: doc(map[string]any{"a": true}, 30),
+		},
+		{
+			name:        "too many steps",
+			args:        []string{"--flag", "a", "--steps", "2", "--yes"},
+			expectError: true,
+			expectedDoc: latest,
+		},
+		{
+			name:        "cancelled",
+			args:        []string{"--to-version", "1"},
+			input:       "n\n",
+			expectError: true,
+			expectedDoc: latest,
+		},
+		{
+			name:        "confirmed",
+			args:        []string{"--to-version", "1"},
+			input:       "y\n",
+			expectedDoc: doc(map[string]any{"a": true}, 10),
+		},
+		{
+			name:           "concurrent edit aborts",
+			args:           []string{"--to-version", "1"},
+			input:          "y\n",
+			concurrentEdit: true,
+			expectError:    true,
+			expectedDoc:    doc(map[string]any{"a": true, "b": true, "c": true}, 30),
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			f, err := attributevalue.MarshalMap(doc(map[string]any{"a": false}, 10))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(ctx, &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			for _, write := range []func() error{
+				func() error { return store.SetFeature(ctx, "a", true) },
+				func() error { return store.SetThrottleProbability(ctx, "search", 20) },
+				func() error { return store.SetFeature(ctx, "b", true) },
+				func() error { return store.SetThrottleProbability(ctx, "search", 30) },
+			} {
+				if err := write(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			in := &editingReader{r: strings.NewReader(tt.input)}
+			if tt.concurrentEdit {
+				in.edit = func() {
+					if err := store.SetFeature(ctx, "c", true); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			c := Command{Store: store, In: in, Out: new(bytes.Buffer)}
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(ctx, &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedDoc, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package segmentcmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Put replaces the members of a segment with the lines of a file, or of In if no file is given.
+type Put struct {
+	Store dynamostore.DynamoStore
+	In    io.Reader
+	Out   io.Writer
+}
+
+func (p Put) Run(args []string) error {
+	if len(args) < 1 {
+		p.Help()
+		return errors.New("No segmentName provided.")
+	}
+	in := p.In
+	if len(args) > 1 {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var members []string
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		if m := strings.TrimSpace(sc.Text()); m != "" {
+			members = append(members, m)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("Error reading members: %s", err.Error())
+	}
+	m, err := p.Store.PutSegment(context.Background(), args[0], members)
+	if err != nil {
+		return fmt.Errorf("Error when writing segment: %s", err.Error())
+	}
+	fmt.Fprintf(p.Out, "%v: %d members in %d chunks\n", args[0], m.Count, len(m.Chunks))
+	return nil
+}
+
+func (p Put) Help() {
+	fmt.Fprintln(p.Out, `usage: flagship segment put [segmentName] [file]
+	Replaces the members of a segment with the lines of file, or of stdin if no file is given.
+	Segments are stored as separate items and can be referenced by the allowSegments and denySegments of throttles:
+		flagship throttle allow add [throttleName] [segmentName] --segment`)
+}
+
+// Rm removes a segment.
+type Rm struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (r Rm) Run(args []string) error {
+	if len(args) < 1 {
+		r.Help()
+		return errors.New("No segmentName provided.")
+	}
+	if err := r.Store.RemoveSegment(context.Background(), args[0]); err != nil {
+		return fmt.Errorf("Error when removing segment: %s", err.Error())
+	}
+	fmt.Fprintf(r.Out, "%v removed!\n", args[0])
+	return nil
+}
+
+func (r Rm) Help() {
+	fmt.Fprintln(r.Out, `usage: flagship segment rm [segmentName]
+	Removes a segment.`)
+}
+
+// Get prints the size of a segment, and whether it contains an identifier if one is given.
+type Get struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (g Get) Run(args []string) error {
+	if len(args) < 1 {
+		g.Help()
+		return errors.New("No segmentName provided.")
+	}
+	ctx := context.Background()
+	m, err := g.Store.LoadSegmentManifest(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("Error when loading segment: %s", err.Error())
+	}
+	fmt.Fprintf(g.Out, "%v: %d members in %d chunks\n", args[0], m.Count, len(m.Chunks))
+	if len(args) < 2 {
+		return nil
+	}
+	contains := false
+	if i := m.Chunk(args[1]); i >= 0 {
+		members, err := g.Store.LoadSegmentChunk(ctx, args[0], m.Generation, i)
+		if err != nil {
+			return fmt.Errorf("Error when loading segment: %s", err.Error())
+		}
+		for _, id := range members {
+			if id == args[1] {
+				contains = true
+				break
+			}
+		}
+	}
+	fmt.Fprintf(g.Out, "%v: %v\n", args[1], contains)
+	return nil
+}
+
+func (g Get) Help() {
+	fmt.Fprintln(g.Out, `usage: flagship segment get [segmentName] [id]
+	Prints the number of members of a segment, and whether id is a member if it is given.`)
+}
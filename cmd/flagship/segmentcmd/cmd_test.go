@@ -0,0 +1,155 @@
+package segmentcmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestPutGetRun(t *testing.T) {
+	var large []string
+	for i := 0; i < 20000; i++ {
+		large = append(large, fmt.Sprintf("tenant-%08d-0000000000000000", i))
+	}
+	tests := []struct {
+		name        string
+		members     []string
+		args        []string
+		expectError bool
+		expectedOut string
+	}{
+		{
+			name:        "no args",
+			expectError: true,
+		},
+		{
+			name:        "invalid name",
+			args:        []string{"a/segment"},
+			expectError: true,
+		},
+		{
+			name:        "empty segment",
+			args:        []string{"{segment}", "tenant-1"},
+			expectedOut: "{segment}: 0 members in 0 chunks\n{segment}: 0 members in 0 chunks\ntenant-1: false\n",
+		},
+		{
+			name:        "duplicate and blank members",
+			members:     []string{"tenant-2", "", "tenant-1", "tenant-2"},
+			args:        []string{"{segment}", "tenant-2"},
+			expectedOut: "{segment}: 2 members in 1 chunks\n{segment}: 2 members in 1 chunks\ntenant-2: true\n",
+		},
+		{
+			name:        "member of a later chunk",
+			members:     large,
+			args:        []string{"{segment}", "tenant-00019999-0000000000000000"},
+			expectedOut: "{segment}: 20000 members in 3 chunks\n{segment}: 20000 members in 3 chunks\ntenant-00019999-0000000000000000: true\n",
+		},
+		{
+			name:        "not a member",
+			members:     large,
+			args:        []string{"{segment}", "tenant-00020000-0000000000000000"},
+			expectedOut: "{segment}: 20000 members in 3 chunks\n{segment}: 20000 members in 3 chunks\ntenant-00020000-0000000000000000: false\n",
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			segment := uuid.NewString()
+			var args []string
+			for _, a := range tt.args {
+				args = append(args, strings.ReplaceAll(a, "{segment}", segment))
+			}
+			store := dynamostore.NewDynamoStoreWithClient(name, "features", dclient)
+			var out bytes.Buffer
+			in := strings.NewReader(strings.Join(tt.members, "\n"))
+			putArgs := args
+			if len(putArgs) > 1 {
+				putArgs = putArgs[:1]
+			}
+			err := Put{Store: store, In: in, Out: &out}.Run(putArgs)
+			if err == nil {
+				err = Get{Store: store, Out: &out}.Run(args)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Put{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Put{}.Run(...) = nil")
+			}
+			if tt.expectError {
+				return
+			}
+			expected := strings.ReplaceAll(tt.expectedOut, "{segment}", segment)
+			if diff := cmp.Diff(expected, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRmRun(t *testing.T) {
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	store := dynamostore.NewDynamoStoreWithClient(name, "features", dclient)
+	var out bytes.Buffer
+	if err := (Put{Store: store, In: strings.NewReader("tenant-1"), Out: &out}).Run([]string{"aSegment"}); err != nil {
+		t.Fatalf("Put{}.Run(...) = %v", err)
+	}
+	if err := (Rm{Store: store, Out: &out}).Run([]string{"aSegment"}); err != nil {
+		t.Errorf("Rm{}.Run(...) = %v", err)
+	}
+	if err := (Get{Store: store, Out: &out}).Run([]string{"aSegment"}); err == nil {
+		t.Errorf("Get{}.Run(...) = nil")
+	}
+	if err := (Rm{Store: store, Out: &out}).Run([]string{"aSegment"}); err == nil {
+		t.Errorf("Rm{}.Run(...) = nil")
+	}
+}
+
+func TestPutFrozenRun(t *testing.T) {
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	store := dynamostore.NewDynamoStoreWithClient(name, "features", dclient)
+	store.Actor, store.Reason = "someone", "incident"
+	if err := store.Freeze(context.Background(), "incident", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := (Put{Store: store, In: strings.NewReader("tenant-1"), Out: &out}).Run([]string{"aSegment"}); err == nil || !strings.Contains(err.Error(), dynamostore.ErrFrozen.Error()) {
+		t.Errorf("expected Put{}.Run(...) to be refused while frozen, got %v", err)
+	}
+	store.BreakGlass = "hotfix"
+	if err := (Put{Store: store, In: strings.NewReader("tenant-1"), Out: &out}).Run([]string{"aSegment"}); err != nil {
+		t.Fatalf("Put{}.Run(...) = %v", err)
+	}
+	if err := (Rm{Store: store, Out: &out}).Run([]string{"aSegment"}); err != nil {
+		t.Fatalf("Rm{}.Run(...) = %v", err)
+	}
+	segment := store
+	segment.Record = dynamostore.SegmentKey("aSegment")
+	for version, expected := range map[int64]string{1: "new", 2: "old"} {
+		e, err := segment.LoadAuditEntry(context.Background(), version)
+		if err != nil {
+			t.Fatalf("LoadAuditEntry(%d) = %v", version, err)
+		}
+		if e.Actor != "someone" || e.BreakGlass != "hotfix" || len(e.Changes) != 1 || e.Changes[0].Name != "aSegment" {
+			t.Errorf("unexpected audit entry of version %d: %+v", version, e)
+		}
+		if expected == "new" && (e.Changes[0].Old != nil || e.Changes[0].New == nil) {
+			t.Errorf("expected version %d to add the segment: %+v", version, e.Changes[0])
+		}
+		if expected == "old" && (e.Changes[0].Old == nil || e.Changes[0].New != nil) {
+			t.Errorf("expected version %d to remove the segment: %+v", version, e.Changes[0])
+		}
+	}
+}
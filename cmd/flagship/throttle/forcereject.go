@@ -0,0 +1,42 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// ForceReject turns forceRejectAll on or off for a throttle.
+type ForceReject struct {
+	Store dynamostore.DynamoStore
+	Value bool
+}
+
+func (f ForceReject) Run(args []string) error {
+	if len(args) < 1 {
+		f.Help()
+		return errors.New("No throttleName provided.")
+	}
+	err := f.Store.SetThrottleForceRejectAll(context.Background(), args[0], f.Value)
+	if err != nil {
+		return fmt.Errorf("Error when setting forceRejectAll: %s", err.Error())
+	}
+	if f.Value {
+		fmt.Printf("%v: all requests are rejected\n", args[0])
+	} else {
+		fmt.Printf("%v: forceRejectAll turned off\n", args[0])
+	}
+	return nil
+}
+
+func (f ForceReject) Help() {
+	if f.Value {
+		fmt.Println(`usage: flagship throttle force-reject on [throttleName]
+	Rejects every request to a throttle, whatever its probability and lists.`)
+		return
+	}
+	fmt.Println(`usage: flagship throttle force-reject off [throttleName]
+	Stops rejecting every request to a throttle, so its probability and lists apply again.`)
+}
@@ -0,0 +1,108 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestForceRejectRun(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		value             bool
+		throttles         any
+		expectError       bool
+		expectedThrottles any
+	}{
+		{
+			name:              "no args",
+			throttles:         map[string]any{"throttles": map[string]any{}},
+			expectedThrottles: map[string]any{"throttles": map[string]any{}},
+			expectError:       true,
+		},
+		{
+			name:              "throttle missing",
+			args:              []string{"aThrottle"},
+			value:             true,
+			throttles:         map[string]any{"throttles": map[string]any{}},
+			expectedThrottles: map[string]any{"throttles": map[string]any{}},
+			expectError:       true,
+		},
+		{
+			name:  "on",
+			args:  []string{"aThrottle"},
+			value: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 50}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 50.0, "forceRejectAll": true}},
+			},
+		},
+		{
+			name: "off",
+			args: []string{"aThrottle"},
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 50, "forceRejectAll": true}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 50.0, "forceRejectAll": false}},
+			},
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			c := ForceReject{Store: store, Value: tt.value}
+			if tt.throttles != nil {
+				f, err := attributevalue.MarshalMap(tt.throttles)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("ForceReject{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("ForceReject{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedThrottles, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
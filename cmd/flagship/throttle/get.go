@@ -0,0 +1,55 @@
+package throttle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/docfile"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Get prints the configuration of a throttle.
+type Get struct {
+	Store dynamostore.DynamoStore
+	Out   io.Writer
+}
+
+func (g Get) Run(args []string) error {
+	f := config.CommandFlags("get")
+	format := f.String("format", "yaml", "Format to write, yaml or json")
+	if err := f.Parse(args); err != nil {
+		g.Help()
+		return err
+	}
+	if f.NArg() < 1 {
+		g.Help()
+		return errors.New("No throttleName provided.")
+	}
+	_, throttles, err := g.Store.Load(context.Background())
+	if err != nil {
+		return fmt.Errorf("Error loading throttles: %s", err.Error())
+	}
+	t, ok := throttles[f.Arg(0)]
+	if !ok {
+		return fmt.Errorf("No throttle found: %s", f.Arg(0))
+	}
+	// Round trip through JSON so the throttle is written with the field names of the document.
+	b, err := json.Marshal(map[string]interface{}{f.Arg(0): t})
+	if err != nil {
+		return err
+	}
+	doc, err := docfile.Decode(b)
+	if err != nil {
+		return err
+	}
+	return docfile.Write(g.Out, doc, *format)
+}
+
+func (g Get) Help() {
+	fmt.Fprintln(g.Out, `usage: flagship throttle get [throttleName] [--format yaml|json]
+	Prints the configuration of a throttle.`)
+}
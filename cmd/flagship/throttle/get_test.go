@@ -0,0 +1,97 @@
+package throttle
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestGetRun(t *testing.T) {
+	throttles := map[string]any{
+		"throttles": map[string]any{
+			"aThrottle": map[string]any{"probability": 50, "whitelist": []uint{1, 2}, "forceRejectAll": true},
+		},
+	}
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+		expected    string
+	}{
+		{
+			name:        "no args",
+			expectError: true,
+		},
+		{
+			name:        "throttle missing",
+			args:        []string{"bThrottle"},
+			expectError: true,
+		},
+		{
+			name: "yaml",
+			args: []string{"aThrottle"},
+			expected: `aThrottle:
+  forceRejectAll: true
+  probability: 50
+  whitelist:
+  - 1
+  - 2
+`,
+		},
+		{
+			name: "json",
+			args: []string{"aThrottle", "--format", "json"},
+			expected: `{
+  "aThrottle": {
+    "forceRejectAll": true,
+    "probability": 50,
+    "whitelist": [
+      1,
+      2
+    ]
+  }
+}
+`,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			f, err := attributevalue.MarshalMap(throttles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f["_pk"] = &types.AttributeValueMemberS{Value: record}
+			dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      f,
+				TableName: &name,
+			})
+			out := new(bytes.Buffer)
+			c := Get{Store: dynamostore.NewDynamoStoreWithClient(name, record, dclient), Out: out}
+			err = c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Get{}.Run(...) = %v", err)
+			}
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Get{}.Run(...) = nil")
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.expected, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// ListAdd adds an identifier to the allow or deny list of a throttle.
+type ListAdd struct {
+	Store dynamostore.DynamoStore
+	// List is either "allow" or "deny".
+	List string
+}
+
+func (l ListAdd) Run(args []string) error {
+	list, args, err := parseList(l.List, "add", args)
+	if err != nil {
+		l.Help()
+		return err
+	}
+	err = l.Store.AddThrottleListEntry(context.Background(), args[0], list, args[1])
+	if err != nil {
+		return fmt.Errorf("Error when adding to %s: %s", list, err.Error())
+	}
+	fmt.Printf("%v: %v added to %s\n", args[0], args[1], list)
+	return nil
+}
+
+func (l ListAdd) Help() {
+	fmt.Printf(`usage: flagship throttle %[1]s add [throttleName] [id] [--segment]
+	Adds an identifier to the %[1]s list of a throttle.
+	With --segment, id is the name of a segment to add to %[1]sSegments instead.
+	Identifiers that are denied are always rejected, even if they are also allowed.
+`, l.List)
+}
+
+// ListRm removes an identifier from the allow or deny list of a throttle.
+type ListRm struct {
+	Store dynamostore.DynamoStore
+	// List is either "allow" or "deny".
+	List string
+}
+
+func (l ListRm) Run(args []string) error {
+	list, args, err := parseList(l.List, "rm", args)
+	if err != nil {
+		l.Help()
+		return err
+	}
+	err = l.Store.RemoveThrottleListEntry(context.Background(), args[0], list, args[1])
+	if err != nil {
+		return fmt.Errorf("Error when removing from %s: %s", list, err.Error())
+	}
+	fmt.Printf("%v: %v removed from %s\n", args[0], args[1], list)
+	return nil
+}
+
+func (l ListRm) Help() {
+	fmt.Printf(`usage: flagship throttle %[1]s rm [throttleName] [id] [--segment]
+	Removes an identifier from the %[1]s list of a throttle.
+	With --segment, id is the name of a segment to remove from %[1]sSegments instead.
+`, l.List)
+}
+
+// parseList returns the throttle list to modify and the positional arguments.
+func parseList(list, name string, args []string) (string, []string, error) {
+	f := config.CommandFlags(name)
+	segment := f.Bool("segment", false, "Modify the list of segments rather than identifiers")
+	if err := f.Parse(args); err != nil {
+		return "", nil, err
+	}
+	if f.NArg() < 2 {
+		return "", nil, errors.New("A throttleName and id must be provided.")
+	}
+	if *segment {
+		if err := dynamostore.ValidateSegment(f.Arg(1)); err != nil {
+			return "", nil, err
+		}
+		list += "Segments"
+	}
+	return list, f.Args(), nil
+}
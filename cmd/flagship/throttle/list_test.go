@@ -0,0 +1,209 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestListRun(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		list              string
+		remove            bool
+		throttles         any
+		expectError       bool
+		expectedThrottles any
+	}{
+		{
+			name: "no args",
+			list: "allow",
+			throttles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "throttle missing",
+			args: []string{"aThrottle", "user-1"},
+			list: "allow",
+			throttles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "add to missing list",
+			args: []string{"aThrottle", "user-1"},
+			list: "allow",
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "allow": []any{"user-1"}},
+				},
+			},
+		},
+		{
+			name: "add to existing list",
+			args: []string{"aThrottle", "user-2"},
+			list: "deny",
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "deny": []any{"user-1"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "deny": []any{"user-1", "user-2"}},
+				},
+			},
+		},
+		{
+			name: "add existing id",
+			args: []string{"aThrottle", "user-1"},
+			list: "allow",
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "allow": []any{"user-1"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "allow": []any{"user-1"}},
+				},
+			},
+		},
+		{
+			name: "add segment",
+			args: []string{"aThrottle", "beta", "--segment"},
+			list: "allow",
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "allow": []any{"user-1"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "allow": []any{"user-1"}, "allowSegments": []any{"beta"}},
+				},
+			},
+		},
+		{
+			name:   "remove segment",
+			args:   []string{"aThrottle", "blocked", "--segment"},
+			list:   "deny",
+			remove: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "denySegments": []any{"blocked"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "denySegments": []any{}},
+				},
+			},
+		},
+		{
+			name:   "remove id",
+			args:   []string{"aThrottle", "user-2"},
+			list:   "allow",
+			remove: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "allow": []any{"user-1", "user-2", "user-3"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "allow": []any{"user-1", "user-3"}},
+				},
+			},
+		},
+		{
+			name:   "remove missing id",
+			args:   []string{"aThrottle", "user-2"},
+			list:   "deny",
+			remove: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "deny": []any{"user-1"}},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "deny": []any{"user-1"}},
+				},
+			},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			var c interface{ Run([]string) error } = ListAdd{Store: store, List: tt.list}
+			if tt.remove {
+				c = ListRm{Store: store, List: tt.list}
+			}
+			if tt.throttles != nil {
+				f, err := attributevalue.MarshalMap(tt.throttles)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("List{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("List{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedThrottles, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
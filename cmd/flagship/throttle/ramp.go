@@ -0,0 +1,158 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Ramp struct {
+	Store dynamostore.DynamoStore
+	// Logger receives a line for every step of the ramp. Defaults to stderr.
+	Logger *log.Logger
+	// Sleep waits between steps. Defaults to time.Sleep.
+	Sleep func(time.Duration)
+}
+
+func (r Ramp) Run(args []string) error {
+	f := config.CommandFlags("ramp")
+	to := f.Float64("to", 100, "Probability to ramp to")
+	step := f.Float64("step", 5, "Amount to change the probability by at each step")
+	every := f.Duration("every", 10*time.Minute, "Time to wait between steps")
+	health := f.String("health", "", "URL that must return a 2xx status for a step to pass")
+	healthCmd := f.String("health-cmd", "", "Command that must exit successfully for a step to pass")
+	onFailure := f.String("on-failure", "rollback", "Action to take when a health check fails: rollback or reject")
+	if err := f.Parse(args); err != nil {
+		r.Help()
+		return err
+	}
+	if f.NArg() < 1 {
+		r.Help()
+		return errors.New("No throttleName provided.")
+	}
+	if *health == "" && *healthCmd == "" {
+		r.Help()
+		return errors.New("One of --health or --health-cmd must be provided.")
+	}
+	if *step <= 0 {
+		return errors.New("--step must be greater than 0.")
+	}
+	if *to < 0 || *to > 100 {
+		return errors.New("--to must be between 0 and 100.")
+	}
+	if *onFailure != "rollback" && *onFailure != "reject" {
+		return fmt.Errorf("Invalid --on-failure action: %s", *onFailure)
+	}
+	if r.Logger == nil {
+		r.Logger = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	if r.Sleep == nil {
+		r.Sleep = time.Sleep
+	}
+	check := func() error {
+		if *health != "" {
+			return checkURL(*health)
+		}
+		return checkCommand(*healthCmd)
+	}
+	return r.ramp(context.Background(), f.Arg(0), *to, *step, *every, check, *onFailure)
+}
+
+func (r Ramp) ramp(ctx context.Context, name string, to, step float64, every time.Duration, check func() error, onFailure string) error {
+	_, throttles, err := r.Store.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("Error loading throttles: %s", err.Error())
+	}
+	t, ok := throttles[name]
+	if !ok {
+		return fmt.Errorf("No throttle found: %s", name)
+	}
+	if t.Ramp != nil {
+		return fmt.Errorf("Throttle %s has a ramp schedule, which takes precedence over its probability. Remove it before ramping.", name)
+	}
+	good := t.Probability
+	r.Logger.Printf("%s: ramping from %v to %v in steps of %v every %v", name, good, to, step, every)
+	if good > to {
+		step = -step
+	}
+	for good != to {
+		next := good + step
+		if (step > 0 && next > to) || (step < 0 && next < to) {
+			next = to
+		}
+		if err := r.Store.SetThrottleProbability(ctx, name, next); err != nil {
+			return fmt.Errorf("Error when setting probability: %s", err.Error())
+		}
+		// --expect-version guards the start of the ramp, later steps are expected to interleave with other writes.
+		r.Store.ExpectVersion = nil
+		r.Logger.Printf("%s: probability set to %v, checking health in %v", name, next, every)
+		r.Sleep(every)
+		if err := check(); err != nil {
+			r.Logger.Printf("%s: health check failed at probability %v: %s", name, next, err.Error())
+			return r.fail(ctx, name, good, next, onFailure)
+		}
+		r.Logger.Printf("%s: health check passed at probability %v", name, next)
+		good = next
+	}
+	r.Logger.Printf("%s: ramp complete at probability %v", name, good)
+	return nil
+}
+
+// fail rolls back or rejects the throttle after a failed health check.
+// The write is made even if the record has been frozen or modified since the ramp began.
+func (r Ramp) fail(ctx context.Context, name string, good, failed float64, onFailure string) error {
+	r.Store.BreakGlass = fmt.Sprintf("ramp of %s failed its health check at probability %v", name, failed)
+	r.Store.ExpectVersion = nil
+	if onFailure == "reject" {
+		if err := r.Store.SetThrottleForceRejectAll(ctx, name, true); err != nil {
+			return fmt.Errorf("Error when setting forceRejectAll: %s", err.Error())
+		}
+		r.Logger.Printf("%s: forceRejectAll set", name)
+		return errors.New("Ramp failed, all traffic is now rejected.")
+	}
+	if err := r.Store.SetThrottleProbability(ctx, name, good); err != nil {
+		return fmt.Errorf("Error when rolling back probability: %s", err.Error())
+	}
+	r.Logger.Printf("%s: probability rolled back to %v", name, good)
+	return fmt.Errorf("Ramp failed, probability rolled back to %v.", good)
+}
+
+func checkURL(url string) error {
+	c := http.Client{Timeout: 10 * time.Second}
+	res, err := c.Get(url)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("%s returned %s", url, res.Status)
+	}
+	return nil
+}
+
+func checkCommand(command string) error {
+	out, err := exec.Command("sh", "-c", command).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %s", err.Error(), out)
+	}
+	return nil
+}
+
+func (Ramp) Help() {
+	fmt.Println(`usage: flagship throttle ramp [throttleName] --to 50 --step 5 --every 10m --health <url>
+	Steps the probability of a throttle towards --to, waiting --every between steps.
+	After each step the health check must pass, either an HTTP GET of --health returning 2xx,
+	or --health-cmd exiting successfully.
+	If a check fails the probability is rolled back to the last good value,
+	or with --on-failure reject, forceRejectAll is set, even if the record has been frozen since.
+	--expect-version only applies to the first step.
+	Throttles with a ramp schedule cannot be ramped.`)
+}
@@ -0,0 +1,248 @@
+package throttle
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRampRun(t *testing.T) {
+	ramp := map[string]any{
+		"steps": []any{map[string]any{"at": "2022-11-29T00:00:00Z", "probability": 50.0}},
+	}
+	tests := []struct {
+		name              string
+		args              []string
+		healthyChecks     int
+		freezeOnFailure   bool
+		throttles         any
+		expectError       bool
+		expectedThrottles any
+	}{
+		{
+			name: "no args",
+			throttles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name: "no health check",
+			args: []string{"aThrottle", "--to", "10"},
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "throttle missing",
+			args: []string{"aThrottle", "--to", "10", "--health", "{url}"},
+			throttles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{},
+			},
+			expectError: true,
+		},
+		{
+			name:          "healthy ramp",
+			args:          []string{"aThrottle", "--to", "12", "--step", "5", "--health", "{url}"},
+			healthyChecks: 3,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 12.0},
+				},
+			},
+		},
+		{
+			name:          "healthy ramp down",
+			args:          []string{"aThrottle", "--to", "0", "--step", "5", "--health", "{url}"},
+			healthyChecks: 2,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 10},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0},
+				},
+			},
+		},
+		{
+			name:          "unhealthy ramp rolls back",
+			args:          []string{"aThrottle", "--to", "20", "--step", "5", "--health", "{url}"},
+			healthyChecks: 1,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 5.0},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name:          "unhealthy ramp rejects",
+			args:          []string{"aThrottle", "--to", "20", "--step", "5", "--health", "{url}", "--on-failure", "reject"},
+			healthyChecks: 1,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 10.0, "forceRejectAll": true},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name:            "unhealthy ramp rolls back a frozen record",
+			args:            []string{"aThrottle", "--to", "20", "--step", "5", "--health", "{url}"},
+			healthyChecks:   1,
+			freezeOnFailure: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 5.0},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "ramp schedule",
+			args: []string{"aThrottle", "--to", "20", "--health", "{url}"},
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0, "ramp": ramp},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0, "ramp": ramp},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "unhealthy command rolls back",
+			args: []string{"aThrottle", "--to", "20", "--step", "5", "--health-cmd", "exit 1"},
+			throttles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0},
+				},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{
+					"aThrottle": map[string]any{"probability": 0.0},
+				},
+			},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			checks := 0
+			health := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				checks++
+				if checks > tt.healthyChecks {
+					if tt.freezeOnFailure {
+						if err := store.Freeze(context.Background(), "incident", time.Now()); err != nil {
+							t.Error(err)
+						}
+					}
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}))
+			defer health.Close()
+			var args []string
+			for _, a := range tt.args {
+				if a == "{url}" {
+					a = health.URL
+				}
+				args = append(args, a)
+			}
+			c := Ramp{Store: store, Logger: log.New(io.Discard, "", 0), Sleep: func(time.Duration) {}}
+			if tt.throttles != nil {
+				f, err := attributevalue.MarshalMap(tt.throttles)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			err := c.Run(args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Ramp{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Ramp{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			delete(res, "freeze")
+			if diff := cmp.Diff(tt.expectedThrottles, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Rm removes a throttle.
+type Rm struct {
+	Store dynamostore.DynamoStore
+}
+
+func (r Rm) Run(args []string) error {
+	if len(args) < 1 {
+		r.Help()
+		return errors.New("No throttleName provided.")
+	}
+	err := r.Store.RemoveThrottle(context.Background(), args[0])
+	if err != nil {
+		return fmt.Errorf("Error when removing throttle: %s", err.Error())
+	}
+	fmt.Printf("%v removed!\n", args[0])
+	return nil
+}
+
+func (Rm) Help() {
+	fmt.Println(`usage: flagship throttle rm [throttleName]
+	Removes a throttle.`)
+}
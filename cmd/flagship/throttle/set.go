@@ -0,0 +1,45 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// Set sets the probability of a throttle, creating it if it does not exist.
+type Set struct {
+	Store dynamostore.DynamoStore
+}
+
+func (s Set) Run(args []string) error {
+	if len(args) < 2 {
+		s.Help()
+		return errors.New("A throttleName and probability must be provided.")
+	}
+	p, err := strconv.ParseFloat(args[1], 64)
+	if err != nil || p < 0 || p > 100 {
+		return fmt.Errorf("Invalid probability %q, expected a number between 0 and 100.", args[1])
+	}
+	ctx := context.Background()
+	err = s.Store.SetThrottleProbability(ctx, args[0], p)
+	if errors.Is(err, dynamostore.ErrThrottleNotFound) {
+		if err = s.Store.CreateThrottle(ctx, args[0], p); err == nil {
+			fmt.Printf("%v created with probability %v\n", args[0], p)
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Error when setting probability: %s", err.Error())
+	}
+	fmt.Printf("%v: probability set to %v\n", args[0], p)
+	return nil
+}
+
+func (Set) Help() {
+	fmt.Println(`usage: flagship throttle set [throttleName] [probability]
+	Sets the percentage of requests that are allowed through a throttle, from 0 to 100.
+	The throttle is created if it does not exist.`)
+}
@@ -0,0 +1,70 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// WhitelistAdd adds a hash result to the whitelist of a throttle.
+type WhitelistAdd struct {
+	Store dynamostore.DynamoStore
+}
+
+func (w WhitelistAdd) Run(args []string) error {
+	hash, err := parseHash(args)
+	if err != nil {
+		w.Help()
+		return err
+	}
+	err = w.Store.AddThrottleWhitelist(context.Background(), args[0], hash)
+	if err != nil {
+		return fmt.Errorf("Error when adding to whitelist: %s", err.Error())
+	}
+	fmt.Printf("%v: %v added to whitelist\n", args[0], hash)
+	return nil
+}
+
+func (WhitelistAdd) Help() {
+	fmt.Println(`usage: flagship throttle whitelist add [throttleName] [hash]
+	Adds a hash result to the whitelist of a throttle, so that inputs with that hash are always allowed.
+	Use flagship hash to calculate the hash of an input.`)
+}
+
+// WhitelistRm removes a hash result from the whitelist of a throttle.
+type WhitelistRm struct {
+	Store dynamostore.DynamoStore
+}
+
+func (w WhitelistRm) Run(args []string) error {
+	hash, err := parseHash(args)
+	if err != nil {
+		w.Help()
+		return err
+	}
+	err = w.Store.RemoveThrottleWhitelist(context.Background(), args[0], hash)
+	if err != nil {
+		return fmt.Errorf("Error when removing from whitelist: %s", err.Error())
+	}
+	fmt.Printf("%v: %v removed from whitelist\n", args[0], hash)
+	return nil
+}
+
+func (WhitelistRm) Help() {
+	fmt.Println(`usage: flagship throttle whitelist rm [throttleName] [hash]
+	Removes a hash result from the whitelist of a throttle.`)
+}
+
+func parseHash(args []string) (uint, error) {
+	if len(args) < 2 {
+		return 0, errors.New("A throttleName and hash must be provided.")
+	}
+	h, err := strconv.ParseUint(args[1], 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid hash %q: %s", args[1], err.Error())
+	}
+	return uint(h), nil
+}
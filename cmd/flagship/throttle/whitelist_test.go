@@ -0,0 +1,143 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestWhitelistRun(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		remove            bool
+		throttles         any
+		expectError       bool
+		expectedThrottles any
+	}{
+		{
+			name:              "no args",
+			throttles:         map[string]any{"throttles": map[string]any{}},
+			expectedThrottles: map[string]any{"throttles": map[string]any{}},
+			expectError:       true,
+		},
+		{
+			name: "invalid hash",
+			args: []string{"aThrottle", "user-1"},
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 0}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 0.0}},
+			},
+			expectError: true,
+		},
+		{
+			name:              "throttle missing",
+			args:              []string{"aThrottle", "42"},
+			throttles:         map[string]any{"throttles": map[string]any{}},
+			expectedThrottles: map[string]any{"throttles": map[string]any{}},
+			expectError:       true,
+		},
+		{
+			name: "add to missing whitelist",
+			args: []string{"aThrottle", "42"},
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 0}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 0.0, "whitelist": []any{42.0}}},
+			},
+		},
+		{
+			name: "add existing hash",
+			args: []string{"aThrottle", "42"},
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []uint{7, 42}}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []any{7.0, 42.0}}},
+			},
+		},
+		{
+			name:   "remove",
+			args:   []string{"aThrottle", "7"},
+			remove: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []uint{7, 42}}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []any{42.0}}},
+			},
+		},
+		{
+			name:   "remove missing hash",
+			args:   []string{"aThrottle", "8"},
+			remove: true,
+			throttles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []uint{7}}},
+			},
+			expectedThrottles: map[string]any{
+				"throttles": map[string]any{"aThrottle": map[string]any{"whitelist": []any{7.0}}},
+			},
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			store := dynamostore.NewDynamoStoreWithClient(name, record, dclient)
+			var c interface{ Run([]string) error } = WhitelistAdd{Store: store}
+			if tt.remove {
+				c = WhitelistRm{Store: store}
+			}
+			if tt.throttles != nil {
+				f, err := attributevalue.MarshalMap(tt.throttles)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Whitelist{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Whitelist{}.Run(...) = nil")
+			}
+			i, err := dclient.GetItem(context.Background(), &dynamodb.GetItemInput{
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: record},
+				},
+				TableName: &name,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res map[string]any
+			err = attributevalue.UnmarshalMap(i.Item, &res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			delete(res, "_pk")
+			delete(res, "version")
+			if diff := cmp.Diff(tt.expectedThrottles, res); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
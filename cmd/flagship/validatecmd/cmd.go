@@ -0,0 +1,63 @@
+package validatecmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/cmd/flagship/config"
+	"github.com/joerdav/flagship/cmd/flagship/docfile"
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+type Command struct {
+	Store dynamostore.DynamoStore
+	In    io.Reader
+	Out   io.Writer
+}
+
+func (c Command) Run(args []string) error {
+	f := config.CommandFlags("validate")
+	file := f.StringP("file", "f", "", "Validate a local JSON or YAML document instead of the live record, - for stdin")
+	printSchema := f.Bool("schema", false, "Print the JSON Schema instead of validating")
+	if err := f.Parse(args); err != nil {
+		c.Help()
+		return err
+	}
+	if *printSchema {
+		_, err := c.Out.Write(flagship.Schema())
+		return err
+	}
+	var problems []flagship.ValidationError
+	source := c.Store.Record
+	if *file != "" {
+		doc, err := docfile.Read(*file, c.In)
+		if err != nil {
+			return fmt.Errorf("Error reading %s: %s", *file, err.Error())
+		}
+		problems = flagship.ValidateDocument(doc)
+		source = *file
+	} else {
+		doc, err := c.Store.LoadRawDocument(context.Background())
+		if err != nil {
+			return fmt.Errorf("Error when loading document: %s", err.Error())
+		}
+		problems = flagship.ValidateDocument(doc)
+	}
+	for _, p := range problems {
+		fmt.Fprintln(c.Out, p.Error())
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s is invalid, %d problems found", source, len(problems))
+	}
+	fmt.Fprintf(c.Out, "%s is valid against schema v%d\n", source, flagship.SchemaVersion)
+	return nil
+}
+
+func (c Command) Help() {
+	fmt.Fprintln(c.Out, `usage: flagship validate [--file <path>] [--schema]
+	Validates the feature document of the record, or a local JSON or YAML file, against the document schema.
+	Exits with an error and lists each problem if the document is invalid.
+	With --schema, prints the JSON Schema.`)
+}
@@ -0,0 +1,100 @@
+package validatecmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/dynamotesting"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		doc         any
+		in          string
+		expectError bool
+		expectedOut string
+	}{
+		{
+			name: "valid record",
+			doc: map[string]any{
+				"features":  map[string]any{"aFeature": true},
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": 5}},
+			},
+			expectedOut: "{record} is valid against schema v2\n",
+		},
+		{
+			name: "invalid record",
+			doc: map[string]any{
+				"features":  map[string]any{"aFeature": nil},
+				"throttles": map[string]any{"aThrottle": map[string]any{"probability": "5"}},
+			},
+			expectError: true,
+			expectedOut: "features.aFeature: must be a boolean, a string, a number, an object or an array, got null\nthrottles.aThrottle.probability: must be a number, got string \"5\"\n",
+		},
+		{
+			name:        "valid file",
+			args:        []string{"--file", "-"},
+			in:          `{"features": {"aFeature": true}}`,
+			expectedOut: "- is valid against schema v2\n",
+		},
+		{
+			name:        "invalid file",
+			args:        []string{"-f", "-"},
+			in:          `{"features": {"aFeature": true}, "throtles": {}}`,
+			expectError: true,
+			expectedOut: "throtles: unknown property\n",
+		},
+		{
+			name:        "not json",
+			args:        []string{"-f", "-"},
+			in:          `features:`,
+			expectError: true,
+		},
+	}
+	name, dclient, close := dynamotesting.CreateLocalTable(t)
+	defer close()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			record := uuid.NewString()
+			if tt.doc != nil {
+				f, err := attributevalue.MarshalMap(tt.doc)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f["_pk"] = &types.AttributeValueMemberS{Value: record}
+				dclient.PutItem(context.Background(), &dynamodb.PutItemInput{
+					Item:      f,
+					TableName: &name,
+				})
+			}
+			var out bytes.Buffer
+			c := Command{
+				Store: dynamostore.NewDynamoStoreWithClient(name, record, dclient),
+				In:    strings.NewReader(tt.in),
+				Out:   &out,
+			}
+			err := c.Run(tt.args)
+			if !tt.expectError && err != nil {
+				t.Errorf("Command{}.Run(...) = %v", err)
+			}
+			if tt.expectError && err == nil {
+				t.Errorf("Command{}.Run(...) = nil")
+			}
+			expected := strings.ReplaceAll(tt.expectedOut, "{record}", record)
+			if diff := cmp.Diff(expected, out.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package flagship
+
+import "context"
+
+// EvaluationContext describes who feature flags are being evaluated for.
+// It is carried in a context.Context so that it can be propagated across a call chain.
+type EvaluationContext struct {
+	// TargetingKey is used as the hash key when ThrottleAllow is called with a nil hashKey.
+	TargetingKey string
+	// Overrides force the result of Bool and ThrottleAllow for the given keys, e.g. when QA testing.
+	Overrides map[string]bool
+}
+
+type evaluationContextKey struct{}
+
+type featureStoreKey struct{}
+
+// ContextWithEvaluationContext returns a copy of ctx carrying ec.
+//
+//	ctx = flagship.ContextWithEvaluationContext(ctx, flagship.EvaluationContext{TargetingKey: userID})
+//	s.ThrottleAllow(ctx, "newThrottleFeature", nil)
+func ContextWithEvaluationContext(ctx context.Context, ec EvaluationContext) context.Context {
+	return context.WithValue(ctx, evaluationContextKey{}, ec)
+}
+
+// EvaluationContextFromContext returns the EvaluationContext carried by ctx.
+// If there is none, the zero value is returned.
+func EvaluationContextFromContext(ctx context.Context) EvaluationContext {
+	ec, _ := ctx.Value(evaluationContextKey{}).(EvaluationContext)
+	return ec
+}
+
+// ContextWithFeatureStore returns a copy of ctx carrying s.
+// This is typically a *Snapshot, so that a whole request sees a single version of the feature document.
+func ContextWithFeatureStore(ctx context.Context, s FeatureStore) context.Context {
+	return context.WithValue(ctx, featureStoreKey{}, s)
+}
+
+// FeatureStoreFromContext returns the FeatureStore carried by ctx, if any.
+//
+//	s, ok := flagship.FeatureStoreFromContext(ctx)
+//	if ok && s.Bool(ctx, "newfeature") {
+//		// New Code
+//	}
+func FeatureStoreFromContext(ctx context.Context) (FeatureStore, bool) {
+	s, ok := ctx.Value(featureStoreKey{}).(FeatureStore)
+	return s, ok
+}
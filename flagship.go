@@ -17,11 +17,11 @@ package flagship
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"hash/fnv"
 	"io"
 	"log"
-	"math"
+	"strings"
 	"sync"
 	"time"
 
@@ -35,10 +35,31 @@ import (
 type BoolFeatureStore interface {
 	// Bool returns the state of the feature flag with the key of `key`:
 	// If the feature is missing from the table then always returns false.
+	// If "killSwitch" is true in the document then the safe default registered with WithSafeDefaults is returned.
+	// If the feature has a schedule in "featureConfigs" then it returns false outside of that window,
+	// or if any of its prerequisites are not met.
 	// Example:
 	// {
 	//     "features": {
 	//         "newFeature": true
+	//     },
+	//     "featureConfigs": {
+	//         "newFeature": {
+	//             // enableAt and disableAt are optional RFC3339 times.
+	//             "enableAt": "2022-11-25T00:00:00Z",
+	//             "disableAt": "2022-11-29T00:00:00Z",
+	//             // prerequisites are optional features that must have the given value, true by default.
+	//             "prerequisites": [{"flag": "parentFeature"}, {"flag": "otherFeature", "value": false}],
+	//             // metadata is optional and does not affect evaluation, see Snapshot.Flags.
+	//             "metadata": {
+	//                 "description": "The new checkout flow",
+	//                 "owner": "payments",
+	//                 "tags": ["payments"],
+	//                 "createdAt": "2022-11-01T00:00:00Z",
+	//                 "expiresAt": "2023-01-01T00:00:00Z",
+	//                 "permanent": false
+	//             }
+	//         }
 	//     }
 	// }
 	//	if s.Bool(context.Background(), "newfeature") {
@@ -55,17 +76,53 @@ type BoolFeatureStore interface {
 type ThrottleFeatureStore interface {
 	// ThrottleAllow returns whether a given hash key is bucketed.
 	// If the feature is missing from the table then always returns false.
+	// If "killSwitch" is true in the document then the safe default registered with WithSafeDefaults is returned.
+	// If hashKey is nil then the TargetingKey of the EvaluationContext in ctx is used.
 	// Example:
 	// {
 	//     "throttles": {
 	//         "newThrottleFeature": {
+	//             // seed is optional, when set it is hashed with the hash key instead of the throttle's key.
+	//             // throttles that share a seed bucket hash keys identically.
+	//             "seed": "experiment-2",
+	//             // hashAlgorithm is optional, one of fnv32a (the default), murmur3 or sha1.
+	//             "hashAlgorithm": "murmur3",
+	//             // buckets is the optional number of buckets that hashes fall into, 10000 by default.
+	//             // raising it allows probabilities finer than 0.01%, with whitelists of hashes in the same range.
+	//             "buckets": 1000000,
+	//             // allow and deny are optional lists of hash keys that are always or never bucketed.
+	//             // deny takes precedence over allow, and both are checked before the whitelist and probability.
+	//             "allow": ["user-1", "user-2"],
+	//             "deny": ["user-3"],
+	//             // allowSegments and denySegments are optional names of segments, large lists of hash keys stored
+	//             // as separate items in the table. Only the part of a segment that could contain a hash key is loaded.
+	//             // if a deny segment cannot be loaded then the hash key is rejected, if an allow segment cannot be
+	//             // loaded then the hash key falls through to the whitelist and probability.
+	//             "allowSegments": ["beta-tenants"],
+	//             "denySegments": ["blocked-tenants"],
 	//             // whitelist is an optional list of hashes that will always be bucketed.
 	//             "whitelist":[10, 3321],
 	//             // probability is the likelihood that a hash is bucketed as a percentage.
 	//             // value is truncated to 2dp
-	//             "probability": 2.5
+	//             "probability": 2.5,
+	//             // ramp is an optional schedule that replaces probability from the time of its first step.
+	//             // when linear is true the probability is interpolated between steps.
+	//             "ramp": {
+	//                 "steps": [
+	//                     {"at": "2022-11-25T00:00:00Z", "probability": 5},
+	//                     {"at": "2022-11-26T00:00:00Z", "probability": 25}
+	//                 ],
+	//                 "linear": false
+	//             },
 	//             // forceRejectAll is an optional flag to force the rejection of all the traffic
-	//             "forceRejectAll": true
+	//             "forceRejectAll": true,
+	//             // enableAt and disableAt are optional RFC3339 times outside of which all traffic is rejected.
+	//             "enableAt": "2022-11-25T00:00:00Z",
+	//             "disableAt": "2022-11-29T00:00:00Z",
+	//             // prerequisites are optional features that must have the given value, true by default.
+	//             "prerequisites": [{"flag": "parentFeature"}],
+	//             // metadata is optional and does not affect evaluation, as for features.
+	//             "metadata": {"owner": "payments", "tags": ["payments"]}
 	//         }
 	//     }
 	// }
@@ -86,12 +143,27 @@ type FeatureStore interface {
 	ThrottleFeatureStore
 }
 
+// Snapshotter is implemented by feature stores that can pin the document they are currently serving.
+// The FeatureStore returned by New implements Snapshotter.
+//
+//	if ss, ok := s.(flagship.Snapshotter); ok {
+//		snap, err := ss.Snapshot(ctx)
+//	}
+type Snapshotter interface {
+	Snapshot(ctx context.Context) (*Snapshot, error)
+}
+
 type featureStoreConfig struct {
 	TableName, RecordName, Region string
+	RecordNames                   []string
+	Environment                   string
 	CacheTTL                      time.Duration
 	Client                        *dynamodb.Client
 	Now                           func() time.Time
 	Logger                        *log.Logger
+	SafeDefaults                  map[string]bool
+	SegmentCacheSize              int
+	StrictValidation              bool
 }
 
 // New constructs a new instance of the feature store client.
@@ -100,10 +172,11 @@ type featureStoreConfig struct {
 //	s, err := flagship.New(context.Background(), flagship.WithClient(client))
 func New(ctx context.Context, opts ...Option) (FeatureStore, error) {
 	cfg := featureStoreConfig{
-		TableName:  "featureFlagStore",
-		RecordName: "features",
-		CacheTTL:   time.Second * 30,
-		Now:        time.Now,
+		TableName:        "featureFlagStore",
+		RecordName:       "features",
+		CacheTTL:         time.Second * 30,
+		Now:              time.Now,
+		SegmentCacheSize: 32 << 20,
 	}
 	for _, o := range opts {
 		o(&cfg)
@@ -120,135 +193,157 @@ func New(ctx context.Context, opts ...Option) (FeatureStore, error) {
 		cfg.Client = dynamodb.NewFromConfig(c)
 	}
 	ds := dynamostore.NewDynamoStoreWithClient(cfg.TableName, cfg.RecordName, cfg.Client)
+	ds.StrictValidation = cfg.StrictValidation
+	if err := dynamostore.ValidateEnvironment(cfg.Environment); err != nil {
+		return nil, fmt.Errorf("flagship - invalid environment: %w", err)
+	}
+	if len(cfg.RecordNames) == 0 {
+		cfg.RecordNames = []string{cfg.RecordName}
+	}
 	s := featureStore{
-		cacheTTL: cfg.CacheTTL,
-		now:      cfg.Now,
-		store:    &ds,
-		logger:   cfg.Logger,
+		records:      cfg.RecordNames,
+		env:          cfg.Environment,
+		cacheTTL:     cfg.CacheTTL,
+		now:          cfg.Now,
+		store:        &ds,
+		logger:       cfg.Logger,
+		safeDefaults: cfg.SafeDefaults,
+		segmentCache: newSegmentCache(&ds, cfg.SegmentCacheSize),
+		strict:       cfg.StrictValidation,
 	}
 	// Initial fetch to check it is working
-	_, _, err := s.fetch(ctx)
+	_, err := s.fetch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("flagship - failed to fetch features: %w", err)
 	}
 	return &s, nil
 }
 
-type throttleConfigInt struct {
-	models.ThrottleConfig
-	// Threshold is an integer representation of Probability. Floor(Probability*100)
-	Threshold uint
-}
-
 type featureStore struct {
-	fetchMutex      sync.Mutex
-	cacheTTL        time.Duration
-	expiry          time.Time
-	now             func() time.Time
-	cachedFeatures  models.Features
-	cachedThrottles map[string]*throttleConfigInt
-	store           store
-	logger          *log.Logger
-}
-
-func (s *featureStore) throttleAllow(ctx context.Context, key string, hashKey io.Reader) bool {
-	_, ts, err := s.fetch(ctx)
-	if err != nil {
-		return false
-	}
-	t := ts[key]
-	if t == nil {
-		return false
-	}
-	if t.ForceRejectAll {
-		return false
-	}
-	h := s.GetHash(ctx, key, hashKey)
-	for _, wl := range t.Whitelist {
-		if h == wl {
-			return true
-		}
-	}
-	if t.Threshold == 0 {
-		return false
-	}
-	if t.Threshold > 100_00 {
-		return true
-	}
-	return h <= t.Threshold
+	fetchMutex     sync.Mutex
+	cacheTTL       time.Duration
+	expiry         time.Time
+	now            func() time.Time
+	cachedSnapshot *Snapshot
+	records        []string
+	env            string
+	store          store
+	logger         *log.Logger
+	safeDefaults   map[string]bool
+	segmentCache   *segmentCache
+	strict         bool
+}
 
+// Snapshot returns the currently cached document, fetching it first if the cache has expired.
+// The returned Snapshot will not change if the underlying document is updated.
+func (s *featureStore) Snapshot(ctx context.Context) (*Snapshot, error) {
+	return s.fetch(ctx)
 }
 
 func (s *featureStore) ThrottleAllow(ctx context.Context, key string, hashKey io.Reader) bool {
-	res := s.throttleAllow(ctx, key, hashKey)
-	if s.logger != nil {
-		s.logger.Printf("flagship.ThrottleAllow('%s') == '%t'", key, res)
+	snap, err := s.fetch(ctx)
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Printf("flagship.ThrottleAllow('%s') == '%t'", key, false)
+		}
+		return false
 	}
-	return res
+	return snap.ThrottleAllow(ctx, key, hashKey)
 }
 
+// GetHash returns the fnv32a bucket of hashKey hashed with key, out of 10000 buckets.
+// It does not know the seed, hash algorithm or buckets of a throttle, use ThrottleFeatureStore.GetHash or
+// Snapshot.GetHash to hash for a throttle as it is configured.
 func GetHash(ctx context.Context, key string, hashKey io.Reader) uint {
-	f := fnv.New32a()
-	f.Write([]byte(key))
-	_, _ = io.Copy(f, hashKey)
-	return uint(f.Sum32()) % 100_00
+	h, _ := GetHashWithAlgorithm(ctx, "fnv32a", key, 0, hashKey)
+	return h
 }
 func (s *featureStore) GetHash(ctx context.Context, key string, hashKey io.Reader) uint {
-	return GetHash(ctx, key, hashKey)
+	snap, err := s.fetch(ctx)
+	if err != nil {
+		snap = s.cached()
+	}
+	return snap.GetHash(ctx, key, hashKey)
 }
 
 func (s *featureStore) Bool(ctx context.Context, key string) bool {
-	f, _, err := s.fetch(ctx)
+	snap, err := s.fetch(ctx)
 	if err != nil {
-		f = s.cachedFeatures
-	}
-	res := f.Bool(key)
-	if s.logger != nil {
-		s.logger.Printf("flagship.Bool('%s') == '%t'", key, res)
+		snap = s.cached()
 	}
-	return res
+	return snap.Bool(ctx, key)
 }
 
-func (s *featureStore) AllBools(ctx context.Context) (allBools map[string]bool) {
-	f, _, err := s.fetch(ctx)
+func (s *featureStore) AllBools(ctx context.Context) map[string]bool {
+	snap, err := s.fetch(ctx)
 	if err != nil {
-		f = s.cachedFeatures
+		snap = s.cached()
 	}
+	return snap.AllBools(ctx)
+}
 
-	allBools = make(map[string]bool)
-
-	for key, value := range f {
-		boolValue, ok := value.(bool)
-		if ok {
-			allBools[key] = boolValue
-		}
+func (s *featureStore) cached() *Snapshot {
+	s.fetchMutex.Lock()
+	defer s.fetchMutex.Unlock()
+	if s.cachedSnapshot == nil {
+		return newSnapshot(models.StoreDocument{}, s.now, s.logger, s.safeDefaults)
 	}
-
-	return
+	return s.cachedSnapshot
 }
 
-func (s *featureStore) fetch(ctx context.Context) (models.Features, map[string]*throttleConfigInt, error) {
+func (s *featureStore) fetch(ctx context.Context) (*Snapshot, error) {
 	s.fetchMutex.Lock()
 	defer s.fetchMutex.Unlock()
 	if s.now().Before(s.expiry) {
-		return s.cachedFeatures, s.cachedThrottles, nil
+		return s.cachedSnapshot, nil
+	}
+	docs, err := s.store.LoadDocuments(ctx, s.keys()...)
+	var invalid *InvalidDocumentError
+	if s.strict && s.cachedSnapshot != nil && errors.As(err, &invalid) {
+		// Keep serving the last valid snapshot until the document is fixed.
+		if s.logger != nil {
+			s.logger.Printf("flagship: keeping last valid snapshot: %v", err)
+		}
+		s.expiry = s.now().Add(s.cacheTTL)
+		return s.cachedSnapshot, nil
 	}
-	f, t, err := s.store.Load(ctx)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	if err := validateDocuments(docs); err != nil {
+		return nil, err
 	}
 	s.expiry = s.now().Add(s.cacheTTL)
-	s.cachedFeatures = f
-	s.cachedThrottles = make(map[string]*throttleConfigInt)
-	for k, th := range t {
-		s.cachedThrottles[k] = &throttleConfigInt{
-			ThrottleConfig: th,
-			Threshold:      uint(math.Floor(th.Probability * 100)),
+	s.cachedSnapshot = newMergedSnapshot(s.records, docs, s.now, s.logger, s.safeDefaults, s.segmentCache.segments(s.now))
+	return s.cachedSnapshot, nil
+}
+
+// keys returns the partition keys of the records in the environment.
+func (s *featureStore) keys() []string {
+	keys := make([]string, len(s.records))
+	for i, r := range s.records {
+		keys[i] = dynamostore.EnvironmentRecord(r, s.env)
+	}
+	return keys
+}
+
+// validateDocuments checks the documents, and their merge, for problems that would prevent evaluation.
+func validateDocuments(docs []models.StoreDocument) error {
+	for _, doc := range append(docs, models.MergeDocuments(docs...)) {
+		if c := doc.PrerequisiteCycle(); c != nil {
+			return fmt.Errorf("prerequisite cycle: %s", strings.Join(c, " -> "))
+		}
+		for k, t := range doc.Throttles {
+			if err := t.Validate(); err != nil {
+				return fmt.Errorf("invalid throttle %s: %w", k, err)
+			}
 		}
 	}
-	return s.cachedFeatures, s.cachedThrottles, nil
+	return nil
 }
 
 type store interface {
-	Load(context.Context) (models.Features, map[string]models.ThrottleConfig, error)
+	LoadDocuments(ctx context.Context, records ...string) ([]models.StoreDocument, error)
+	LoadDocumentsAt(ctx context.Context, at time.Time, records ...string) ([]models.StoreDocument, error)
+	segmentLoader
 }
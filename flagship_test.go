@@ -2,17 +2,20 @@ package flagship_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
 	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/internal/dynamostore"
 )
 
 func setThrottle(d *dynamodb.Client, key string, value float64, whitelist []string, table, record string, forceRejectAll *bool) error {
@@ -390,6 +393,1133 @@ func TestAllBools(t *testing.T) {
 	}
 }
 
+func TestSchedule(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	window := map[string]any{
+		"enableAt":  "2022-11-25T00:00:00Z",
+		"disableAt": "2022-11-29T00:00:00Z",
+	}
+	doc := map[string]any{
+		"features": map[string]any{
+			"someflag": true,
+		},
+		"featureConfigs": map[string]any{
+			"someflag": window,
+		},
+		"throttles": map[string]any{
+			"somethrottle": map[string]any{
+				"probability": 100,
+				"enableAt":    window["enableAt"],
+				"disableAt":   window["disableAt"],
+			},
+		},
+	}
+	tests := []struct {
+		name           string
+		now            time.Time
+		expectedResult bool
+	}{
+		{
+			name:           "given time is before window, return false",
+			now:            time.Date(2022, 11, 24, 23, 59, 59, 0, time.UTC),
+			expectedResult: false,
+		},
+		{
+			name:           "given time is at start of window, return true",
+			now:            time.Date(2022, 11, 25, 0, 0, 0, 0, time.UTC),
+			expectedResult: true,
+		},
+		{
+			name:           "given time is within window, return true",
+			now:            time.Date(2022, 11, 27, 0, 0, 0, 0, time.UTC),
+			expectedResult: true,
+		},
+		{
+			name:           "given time is at end of window, return false",
+			now:            time.Date(2022, 11, 29, 0, 0, 0, 0, time.UTC),
+			expectedResult: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			item, err := attributevalue.MarshalMap(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+				flagship.WithClock(func() time.Time {
+					return tt.now
+				}),
+			)
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			if b := store.Bool(context.Background(), "someflag"); b != tt.expectedResult {
+				t.Errorf("expected flag to be %v, was %v", tt.expectedResult, b)
+			}
+			if r := store.ThrottleAllow(context.Background(), "somethrottle", strings.NewReader("an input")); r != tt.expectedResult {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedResult, r)
+			}
+		})
+	}
+}
+
+func TestRamp(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	start := time.Date(2022, 11, 25, 0, 0, 0, 0, time.UTC)
+	steps := []any{
+		map[string]any{"at": start.Format(time.RFC3339), "probability": 10},
+		map[string]any{"at": start.Add(2 * time.Hour).Format(time.RFC3339), "probability": 30},
+	}
+	tests := []struct {
+		name           string
+		linear         bool
+		now            time.Time
+		expectedResult bool
+	}{
+		{
+			name:           "given time is before ramp, use probability",
+			now:            start.Add(-time.Hour),
+			expectedResult: true,
+		},
+		{
+			name:           "given time is after first step, use step probability",
+			now:            start.Add(time.Hour),
+			expectedResult: false,
+		},
+		{
+			name:           "given time is after last step, use step probability",
+			now:            start.Add(3 * time.Hour),
+			expectedResult: true,
+		},
+		{
+			name:           "given linear ramp early between steps, use interpolated probability",
+			linear:         true,
+			now:            start.Add(30 * time.Minute),
+			expectedResult: false,
+		},
+		{
+			name:           "given linear ramp late between steps, use interpolated probability",
+			linear:         true,
+			now:            start.Add(90 * time.Minute),
+			expectedResult: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			// "an input" hashes to 1898 for someFeature, so is allowed from a probability of 18.98.
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"throttles": map[string]any{
+					"someFeature": map[string]any{
+						"probability": 100,
+						"ramp": map[string]any{
+							"steps":  steps,
+							"linear": tt.linear,
+						},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+				flagship.WithClock(func() time.Time {
+					return tt.now
+				}),
+			)
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			if r := store.ThrottleAllow(context.Background(), "someFeature", strings.NewReader("an input")); r != tt.expectedResult {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedResult, r)
+			}
+		})
+	}
+}
+
+func TestThrottleHashing(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	tests := []struct {
+		name           string
+		key            string
+		seed           string
+		algorithm      string
+		buckets        uint
+		expectedHash   uint
+		expectedResult bool
+	}{
+		{
+			name:           "given no seed, hash with the key",
+			key:            "someFeature",
+			expectedHash:   1898,
+			expectedResult: false,
+		},
+		{
+			name:           "given a seed, hash with the seed",
+			key:            "someFeature",
+			seed:           "exp-2",
+			expectedHash:   128,
+			expectedResult: true,
+		},
+		{
+			name:           "given a shared seed, hash the same as other throttles with the seed",
+			key:            "otherFeature",
+			seed:           "exp-2",
+			expectedHash:   128,
+			expectedResult: true,
+		},
+		{
+			name:           "given murmur3, hash with murmur3",
+			key:            "someFeature",
+			algorithm:      "murmur3",
+			expectedHash:   3993,
+			expectedResult: false,
+		},
+		{
+			name:           "given sha1, hash with sha1",
+			key:            "someFeature",
+			algorithm:      "sha1",
+			expectedHash:   9259,
+			expectedResult: false,
+		},
+		{
+			name:           "given more buckets, hash into more buckets",
+			key:            "someFeature",
+			buckets:        1_000_000,
+			expectedHash:   51898,
+			expectedResult: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"throttles": map[string]any{
+					tt.key: map[string]any{
+						"probability":   10,
+						"seed":          tt.seed,
+						"hashAlgorithm": tt.algorithm,
+						"buckets":       tt.buckets,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+			)
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			if h := store.GetHash(context.Background(), tt.key, strings.NewReader("an input")); h != tt.expectedHash {
+				t.Errorf("expected hash to be %v, was %v", tt.expectedHash, h)
+			}
+			if r := store.ThrottleAllow(context.Background(), tt.key, strings.NewReader("an input")); r != tt.expectedResult {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedResult, r)
+			}
+		})
+	}
+}
+
+func TestAllowDeny(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	tests := []struct {
+		name           string
+		throttle       map[string]any
+		input          string
+		expectedResult bool
+	}{
+		{
+			name:           "given an allowed id, allow regardless of probability",
+			throttle:       map[string]any{"probability": 0, "allow": []string{"an input"}},
+			input:          "an input",
+			expectedResult: true,
+		},
+		{
+			name:           "given an id that is not allowed, use probability",
+			throttle:       map[string]any{"probability": 0, "allow": []string{"another input"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+		{
+			name:           "given a denied id, reject regardless of probability",
+			throttle:       map[string]any{"probability": 100, "deny": []string{"an input"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+		{
+			name:           "given an id that is not denied, use probability",
+			throttle:       map[string]any{"probability": 100, "deny": []string{"another input"}},
+			input:          "an input",
+			expectedResult: true,
+		},
+		{
+			name:           "given an id that is allowed and denied, reject",
+			throttle:       map[string]any{"probability": 100, "allow": []string{"an input"}, "deny": []string{"an input"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+		{
+			name:           "given a denied id with a whitelisted hash, reject",
+			throttle:       map[string]any{"probability": 0, "whitelist": []uint{1898}, "deny": []string{"an input"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"throttles": map[string]any{
+					"someFeature": tt.throttle,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+			)
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			if r := store.ThrottleAllow(context.Background(), "someFeature", strings.NewReader(tt.input)); r != tt.expectedResult {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedResult, r)
+			}
+			ctx := flagship.ContextWithEvaluationContext(context.Background(), flagship.EvaluationContext{TargetingKey: tt.input})
+			if r := store.ThrottleAllow(ctx, "someFeature", nil); r != tt.expectedResult {
+				t.Errorf("expected throttle with targeting key to be %v, was %v", tt.expectedResult, r)
+			}
+		})
+	}
+}
+
+func TestSegments(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	ds := dynamostore.NewDynamoStoreWithClient(tableName, "", testClient)
+	var tenants []string
+	for i := 0; i < 20000; i++ {
+		tenants = append(tenants, fmt.Sprintf("tenant-%08d-0000000000000000", i))
+	}
+	if _, err := ds.PutSegment(context.Background(), "tenants", tenants); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ds.PutSegment(context.Background(), "blocked", []string{"tenant-00000001-0000000000000000"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name           string
+		throttle       map[string]any
+		input          string
+		expectedResult bool
+	}{
+		{
+			name:           "given a member of an allowed segment, allow",
+			throttle:       map[string]any{"probability": 0, "allowSegments": []string{"tenants"}},
+			input:          "tenant-00015000-0000000000000000",
+			expectedResult: true,
+		},
+		{
+			name:           "given a non member of an allowed segment, use probability",
+			throttle:       map[string]any{"probability": 0, "allowSegments": []string{"tenants"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+		{
+			name:           "given a member of a denied segment, reject",
+			throttle:       map[string]any{"probability": 100, "allowSegments": []string{"tenants"}, "denySegments": []string{"blocked"}},
+			input:          "tenant-00000001-0000000000000000",
+			expectedResult: false,
+		},
+		{
+			name:           "given a non member of a denied segment, use probability",
+			throttle:       map[string]any{"probability": 100, "denySegments": []string{"blocked"}},
+			input:          "tenant-00000002-0000000000000000",
+			expectedResult: true,
+		},
+		{
+			name:           "given a missing denied segment, reject",
+			throttle:       map[string]any{"probability": 100, "denySegments": []string{"missing"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+		{
+			name:           "given a missing allowed segment, use probability",
+			throttle:       map[string]any{"probability": 100, "allowSegments": []string{"missing"}},
+			input:          "an input",
+			expectedResult: true,
+		},
+		{
+			name:           "given a missing allowed segment and no probability, reject",
+			throttle:       map[string]any{"probability": 0, "allowSegments": []string{"missing"}},
+			input:          "an input",
+			expectedResult: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"throttles": map[string]any{
+					"someFeature": tt.throttle,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+			)
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			if r := store.ThrottleAllow(context.Background(), "someFeature", strings.NewReader(tt.input)); r != tt.expectedResult {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedResult, r)
+			}
+		})
+	}
+}
+
+func TestFlags(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	record := uuid.New().String()
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"features": map[string]any{
+			"newCheckout": true,
+			"oldSearch":   false,
+		},
+		"featureConfigs": map[string]any{
+			"newCheckout": map[string]any{
+				"metadata": map[string]any{
+					"description": "The new checkout flow",
+					"owner":       "payments",
+					"tags":        []string{"payments"},
+					"createdAt":   "2022-11-01T00:00:00Z",
+					"expiresAt":   "2023-01-01T00:00:00Z",
+				},
+			},
+		},
+		"throttles": map[string]any{
+			"newSearch": map[string]any{
+				"probability": 10,
+				"metadata": map[string]any{
+					"owner":     "search",
+					"permanent": true,
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item["_pk"] = &types.AttributeValueMemberS{Value: record}
+	_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: &tableName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := flagship.New(
+		context.Background(),
+		flagship.WithClient(testClient),
+		flagship.WithTableName(tableName),
+		flagship.WithRecordName(record),
+		flagship.WithRegion(testRegion),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error got %v", err)
+	}
+	snap, err := store.(flagship.Snapshotter).Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error got %v", err)
+	}
+	created := time.Date(2022, 11, 1, 0, 0, 0, 0, time.UTC)
+	expires := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := []flagship.Flag{
+		{
+			Key:  "newCheckout",
+			Type: flagship.FeatureFlag,
+			Metadata: flagship.Metadata{
+				Description: "The new checkout flow",
+				Owner:       "payments",
+				Tags:        []string{"payments"},
+				CreatedAt:   &created,
+				ExpiresAt:   &expires,
+			},
+		},
+		{Key: "oldSearch", Type: flagship.FeatureFlag},
+		{Key: "newSearch", Type: flagship.ThrottleFlag, Metadata: flagship.Metadata{Owner: "search", Permanent: true}},
+	}
+	if diff := cmp.Diff(expected, snap.Flags()); diff != "" {
+		t.Error(diff)
+	}
+	f, ok := snap.Flag("newCheckout")
+	if !ok {
+		t.Fatal("expected newCheckout to exist")
+	}
+	if !f.Expired(expires) || f.Expired(created) || !f.HasTag("payments") {
+		t.Errorf("unexpected metadata %+v", f.Metadata)
+	}
+}
+
+func TestStrictValidation(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	putDoc := func(record string, doc map[string]any) {
+		t.Helper()
+		item, err := attributevalue.MarshalMap(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item["_pk"] = &types.AttributeValueMemberS{Value: record}
+		_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+			Item:      item,
+			TableName: &tableName,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	invalid := map[string]any{
+		"features":  map[string]any{"someflag": nil},
+		"throttles": map[string]any{"someThrottle": map[string]any{"probability": "5"}},
+	}
+	t.Run("given an invalid document, New returns an error", func(t *testing.T) {
+		record := uuid.New().String()
+		putDoc(record, invalid)
+		_, err := flagship.New(context.Background(),
+			flagship.WithClient(testClient),
+			flagship.WithTableName(tableName),
+			flagship.WithRecordName(record),
+			flagship.WithRegion(testRegion),
+			flagship.WithStrictValidation())
+		var ide *flagship.InvalidDocumentError
+		if !errors.As(err, &ide) {
+			t.Fatalf("expected an InvalidDocumentError, got %v", err)
+		}
+		if len(ide.Errors) != 2 {
+			t.Errorf("expected 2 problems, got %v", ide.Errors)
+		}
+	})
+	t.Run("given a document becomes invalid, keep the last valid snapshot", func(t *testing.T) {
+		record := uuid.New().String()
+		putDoc(record, map[string]any{
+			"features": map[string]any{"someflag": true},
+		})
+		currentTime := time.Time{}
+		store, err := flagship.New(context.Background(),
+			flagship.WithClient(testClient),
+			flagship.WithTableName(tableName),
+			flagship.WithRecordName(record),
+			flagship.WithRegion(testRegion),
+			flagship.WithClock(func() time.Time {
+				return currentTime
+			}),
+			flagship.WithStrictValidation())
+		if err != nil {
+			t.Fatalf("unexpected error got %v", err)
+		}
+		putDoc(record, invalid)
+		currentTime = currentTime.Add(time.Hour)
+		if b := store.Bool(context.Background(), "someflag"); !b {
+			t.Errorf("expected flag to be true, was false")
+		}
+		if _, err := store.(flagship.Snapshotter).Snapshot(context.Background()); err != nil {
+			t.Errorf("unexpected error got %v", err)
+		}
+	})
+}
+
+func TestPrerequisites(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	tests := []struct {
+		name             string
+		features         map[string]any
+		prerequisites    map[string]any
+		expectNewError   bool
+		expectedBool     bool
+		expectedThrottle bool
+	}{
+		{
+			name:     "given prerequisite is on, return true",
+			features: map[string]any{"parent": true, "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent"}},
+			},
+			expectedBool:     true,
+			expectedThrottle: true,
+		},
+		{
+			name:     "given prerequisite is off, return false",
+			features: map[string]any{"parent": false, "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent"}},
+			},
+			expectedBool:     false,
+			expectedThrottle: false,
+		},
+		{
+			name:     "given prerequisite requires false and is off, return true",
+			features: map[string]any{"parent": false, "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent", "value": false}},
+			},
+			expectedBool:     true,
+			expectedThrottle: false,
+		},
+		{
+			name:     "given prerequisite of prerequisite is off, return false",
+			features: map[string]any{"grandparent": false, "parent": true, "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent"}},
+				"parent":   []any{map[string]any{"flag": "grandparent"}},
+			},
+			expectedBool:     false,
+			expectedThrottle: false,
+		},
+		{
+			name:     "given prerequisite requires a string value, compare values",
+			features: map[string]any{"parent": "blue", "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent", "value": "blue"}},
+			},
+			expectedBool:     true,
+			expectedThrottle: false,
+		},
+		{
+			name:     "given prerequisites form a cycle, return an error",
+			features: map[string]any{"parent": true, "someflag": true},
+			prerequisites: map[string]any{
+				"someflag": []any{map[string]any{"flag": "parent"}},
+				"parent":   []any{map[string]any{"flag": "someflag"}},
+			},
+			expectNewError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			featureConfigs := make(map[string]any)
+			for k, p := range tt.prerequisites {
+				featureConfigs[k] = map[string]any{"prerequisites": p}
+			}
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"features":       tt.features,
+				"featureConfigs": featureConfigs,
+				"throttles": map[string]any{
+					"somethrottle": map[string]any{
+						"probability":   100,
+						"prerequisites": []any{map[string]any{"flag": "parent"}},
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+			)
+			if tt.expectNewError {
+				if err == nil {
+					t.Errorf("expected an error got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error got %v", err)
+			}
+			if b := store.Bool(context.Background(), "someflag"); b != tt.expectedBool {
+				t.Errorf("expected flag to be %v, was %v", tt.expectedBool, b)
+			}
+			if r := store.ThrottleAllow(context.Background(), "somethrottle", strings.NewReader("an input")); r != tt.expectedThrottle {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedThrottle, r)
+			}
+		})
+	}
+}
+
+func TestKillSwitch(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	tests := []struct {
+		name             string
+		killSwitch       bool
+		expectedBool     bool
+		expectedThrottle bool
+	}{
+		{
+			name:             "given kill switch is off, return document values",
+			killSwitch:       false,
+			expectedBool:     true,
+			expectedThrottle: false,
+		},
+		{
+			name:             "given kill switch is on, return safe defaults",
+			killSwitch:       true,
+			expectedBool:     false,
+			expectedThrottle: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			record := uuid.New().String()
+			item, err := attributevalue.MarshalMap(map[string]any{
+				"killSwitch": tt.killSwitch,
+				"features": map[string]any{
+					"someflag": true,
+				},
+				"throttles": map[string]any{
+					"somethrottle": map[string]any{
+						"probability": 0,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			item["_pk"] = &types.AttributeValueMemberS{Value: record}
+			_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+				Item:      item,
+				TableName: &tableName,
+			})
+			if err != nil {
+				t.Errorf("unexpected error got %v", err)
+			}
+			store, err := flagship.New(
+				context.Background(),
+				flagship.WithClient(testClient),
+				flagship.WithTableName(tableName),
+				flagship.WithRecordName(record),
+				flagship.WithRegion(testRegion),
+				flagship.WithSafeDefaults(map[string]bool{
+					"somethrottle": true,
+				}),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error got %v", err)
+			}
+			if b := store.Bool(context.Background(), "someflag"); b != tt.expectedBool {
+				t.Errorf("expected flag to be %v, was %v", tt.expectedBool, b)
+			}
+			if r := store.ThrottleAllow(context.Background(), "somethrottle", strings.NewReader("an input")); r != tt.expectedThrottle {
+				t.Errorf("expected throttle to be %v, was %v", tt.expectedThrottle, r)
+			}
+		})
+	}
+}
+
+func TestRecordNames(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	global, payments := uuid.New().String(), uuid.New().String()
+	docs := map[string]map[string]any{
+		global: {
+			"features": map[string]any{
+				"globalflag":   true,
+				"sharedflag":   true,
+				"paymentsflag": false,
+			},
+		},
+		payments: {
+			"features": map[string]any{
+				"sharedflag":   false,
+				"paymentsflag": true,
+			},
+		},
+	}
+	for record, doc := range docs {
+		item, err := attributevalue.MarshalMap(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item["_pk"] = &types.AttributeValueMemberS{Value: record}
+		_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+			Item:      item,
+			TableName: &tableName,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error got %v", err)
+		}
+	}
+	store, err := flagship.New(
+		context.Background(),
+		flagship.WithClient(testClient),
+		flagship.WithTableName(tableName),
+		flagship.WithRecordNames(global, payments),
+		flagship.WithRegion(testRegion),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error got %v", err)
+	}
+	tests := []struct {
+		key          string
+		expectedBool bool
+	}{
+		{key: "globalflag", expectedBool: true},
+		{key: "sharedflag", expectedBool: false},
+		{key: "paymentsflag", expectedBool: true},
+		{key: global + "/sharedflag", expectedBool: true},
+		{key: global + "/paymentsflag", expectedBool: false},
+		{key: payments + "/globalflag", expectedBool: false},
+		{key: payments + "/paymentsflag", expectedBool: true},
+	}
+	for _, tt := range tests {
+		if b := store.Bool(context.Background(), tt.key); b != tt.expectedBool {
+			t.Errorf("expected %s to be %v, was %v", tt.key, tt.expectedBool, b)
+		}
+	}
+	t.Run("if a record is missing should return error", func(t *testing.T) {
+		_, err := flagship.New(
+			context.Background(),
+			flagship.WithClient(testClient),
+			flagship.WithTableName(tableName),
+			flagship.WithRecordNames(global, uuid.New().String()),
+			flagship.WithRegion(testRegion),
+		)
+		if !errors.Is(err, dynamostore.ErrEmptyRecord) {
+			t.Errorf("expected ErrEmptyRecord got %v", err)
+		}
+	})
+	t.Run("if the only record is missing should return error", func(t *testing.T) {
+		_, err := flagship.New(
+			context.Background(),
+			flagship.WithClient(testClient),
+			flagship.WithTableName(tableName),
+			flagship.WithRecordNames(uuid.New().String()),
+			flagship.WithRegion(testRegion),
+		)
+		if !errors.Is(err, dynamostore.ErrEmptyRecord) {
+			t.Errorf("expected ErrEmptyRecord got %v", err)
+		}
+	})
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	global, payments := uuid.New().String(), uuid.New().String()
+	docs := map[string]map[string]any{
+		global: {
+			"features": map[string]any{"globalflag": true},
+			"version":  3,
+		},
+		payments: {
+			"features": map[string]any{"paymentsflag": true},
+		},
+	}
+	for record, doc := range docs {
+		item, err := attributevalue.MarshalMap(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item["_pk"] = &types.AttributeValueMemberS{Value: record}
+		_, err = testClient.PutItem(context.Background(), &dynamodb.PutItemInput{
+			Item:      item,
+			TableName: &tableName,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error got %v", err)
+		}
+	}
+	snapshot := func(t *testing.T, records ...string) *flagship.Snapshot {
+		store, err := flagship.New(
+			context.Background(),
+			flagship.WithClient(testClient),
+			flagship.WithTableName(tableName),
+			flagship.WithRecordNames(records...),
+			flagship.WithRegion(testRegion),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error got %v", err)
+		}
+		snap, err := store.(flagship.Snapshotter).Snapshot(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error got %v", err)
+		}
+		return snap
+	}
+	t.Run("a single record has its version", func(t *testing.T) {
+		if v := snapshot(t, global).Version(); v != 3 {
+			t.Errorf("expected version 3, got %d", v)
+		}
+	})
+	t.Run("an unversioned record has version 0", func(t *testing.T) {
+		if v := snapshot(t, payments).Version(); v != 0 {
+			t.Errorf("expected version 0, got %d", v)
+		}
+	})
+	t.Run("merged records have a version each", func(t *testing.T) {
+		snap := snapshot(t, global, payments)
+		if v := snap.Version(); v != 0 {
+			t.Errorf("expected version 0, got %d", v)
+		}
+		expected := map[string]int64{global: 3, payments: 0}
+		if diff := cmp.Diff(expected, snap.Versions()); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
+
+func TestSnapshotAt(t *testing.T) {
+	testClient, testRegion, err := newTestClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableName := createLocalTable(t, testClient)
+	t.Cleanup(func() {
+		deleteLocalTable(t, testClient, tableName)
+	})
+	ctx := context.Background()
+	record := uuid.New().String()
+	item, err := attributevalue.MarshalMap(map[string]any{
+		"features": map[string]any{"newCheckout": false},
+		"throttles": map[string]any{
+			"search": map[string]any{"probability": 100},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item["_pk"] = &types.AttributeValueMemberS{Value: record}
+	_, err = testClient.PutItem(ctx, &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: &tableName,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds := dynamostore.NewDynamoStoreWithClient(tableName, record, testClient)
+	// tick returns a time between writes.
+	tick := func() time.Time {
+		time.Sleep(10 * time.Millisecond)
+		at := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		return at
+	}
+	beforeHistory := tick()
+	if err := ds.SetFeature(ctx, "newCheckout", true); err != nil {
+		t.Fatal(err)
+	}
+	launched := tick()
+	if err := ds.SetThrottleProbability(ctx, "search", 0); err != nil {
+		t.Fatal(err)
+	}
+	throttled := tick()
+	if err := ds.SetFeature(ctx, "newCheckout", false); err != nil {
+		t.Fatal(err)
+	}
+	store, err := flagship.New(
+		ctx,
+		flagship.WithClient(testClient),
+		flagship.WithTableName(tableName),
+		flagship.WithRecordName(record),
+		flagship.WithRegion(testRegion),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error got %v", err)
+	}
+	tests := []struct {
+		name            string
+		at              time.Time
+		expectedVersion int64
+		expectedBool    bool
+		expectedAllow   bool
+	}{
+		{name: "after launch", at: launched, expectedVersion: 1, expectedBool: true, expectedAllow: true},
+		{name: "after throttling", at: throttled, expectedVersion: 2, expectedBool: true, expectedAllow: false},
+		{name: "now", at: time.Now(), expectedVersion: 3, expectedBool: false, expectedAllow: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			snap, err := store.(flagship.TimeTraveler).SnapshotAt(ctx, tt.at)
+			if err != nil {
+				t.Fatalf("unexpected error got %v", err)
+			}
+			if v := snap.Version(); v != tt.expectedVersion {
+				t.Errorf("expected version %d, got %d", tt.expectedVersion, v)
+			}
+			if b := snap.Bool(ctx, "newCheckout"); b != tt.expectedBool {
+				t.Errorf("expected newCheckout to be %v, was %v", tt.expectedBool, b)
+			}
+			if a := snap.ThrottleAllow(ctx, "search", strings.NewReader("user123")); a != tt.expectedAllow {
+				t.Errorf("expected search to allow user123 %v, was %v", tt.expectedAllow, a)
+			}
+		})
+	}
+	t.Run("before the audit log begins", func(t *testing.T) {
+		_, err := store.(flagship.TimeTraveler).SnapshotAt(ctx, beforeHistory)
+		if !errors.Is(err, flagship.ErrNoHistory) {
+			t.Errorf("expected ErrNoHistory, got %v", err)
+		}
+	})
+}
+
 func TestNew(t *testing.T) {
 	testClient, _, err := newTestClient()
 	if err != nil {
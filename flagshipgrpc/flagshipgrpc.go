@@ -0,0 +1,169 @@
+/*
+Package flagshipgrpc provides gRPC interceptors that propagate feature flag context across services.
+
+On the server, a snapshot of the feature document and the caller's EvaluationContext are added to the request context:
+
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(flagshipgrpc.UnaryServerInterceptor(fs)),
+		grpc.StreamInterceptor(flagshipgrpc.StreamServerInterceptor(fs)),
+	)
+
+	func (s *server) Checkout(ctx context.Context, req *pb.CheckoutRequest) (*pb.CheckoutResponse, error) {
+		fs, _ := flagship.FeatureStoreFromContext(ctx)
+		if fs.ThrottleAllow(ctx, "newcheckout", nil) {
+			// New Code
+		}
+	}
+
+Overrides sent by the client are ignored unless the server trusts them, as they let a caller choose the value of any flag:
+
+	grpc.UnaryInterceptor(flagshipgrpc.UnaryServerInterceptor(fs, flagshipgrpc.WithTrustOverridesIf(fromInternalNetwork)))
+
+On the client, the EvaluationContext is forwarded as metadata:
+
+	conn, err := grpc.Dial(addr,
+		grpc.WithUnaryInterceptor(flagshipgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(flagshipgrpc.StreamClientInterceptor()),
+	)
+*/
+package flagshipgrpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/joerdav/flagship"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// TargetingKeyHeader is the metadata key used to forward EvaluationContext.TargetingKey.
+	TargetingKeyHeader = "flagship-targeting-key"
+	// OverrideHeader is the metadata key used to forward EvaluationContext.Overrides, one "key=bool" value per override.
+	OverrideHeader = "flagship-override"
+)
+
+// ServerOption configures the server interceptors.
+type ServerOption func(*serverConfig)
+
+type serverConfig struct {
+	trustOverrides func(ctx context.Context) bool
+}
+
+// WithTrustOverrides makes the server interceptors honour the overrides sent by every client.
+// By default only the targeting key is kept.
+func WithTrustOverrides() ServerOption {
+	return WithTrustOverridesIf(func(context.Context) bool { return true })
+}
+
+// WithTrustOverridesIf makes the server interceptors honour the overrides sent by a client if trusted returns true
+// for the context of the call, for example after checking its peer or credentials.
+func WithTrustOverridesIf(trusted func(ctx context.Context) bool) ServerOption {
+	return func(c *serverConfig) {
+		c.trustOverrides = trusted
+	}
+}
+
+// UnaryServerInterceptor adds a snapshot of fs and the EvaluationContext sent by the client to the context of each unary call.
+func UnaryServerInterceptor(fs flagship.FeatureStore, opts ...ServerOption) grpc.UnaryServerInterceptor {
+	cfg := newServerConfig(opts)
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(cfg.serverContext(ctx, fs), req)
+	}
+}
+
+// StreamServerInterceptor adds a snapshot of fs and the EvaluationContext sent by the client to the context of each stream.
+func StreamServerInterceptor(fs flagship.FeatureStore, opts ...ServerOption) grpc.StreamServerInterceptor {
+	cfg := newServerConfig(opts)
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: cfg.serverContext(ss.Context(), fs)})
+	}
+}
+
+func newServerConfig(opts []ServerOption) serverConfig {
+	var cfg serverConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return cfg
+}
+
+// UnaryClientInterceptor forwards the EvaluationContext of each unary call as metadata.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(clientContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor forwards the EvaluationContext of each stream as metadata.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(clientContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (c serverConfig) serverContext(ctx context.Context, fs flagship.FeatureStore) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ec := evaluationContextFromMetadata(md)
+		if c.trustOverrides == nil || !c.trustOverrides(ctx) {
+			ec.Overrides = nil
+		}
+		ctx = flagship.ContextWithEvaluationContext(ctx, ec)
+	}
+	if ss, ok := fs.(flagship.Snapshotter); ok {
+		// If the document cannot be fetched fall back to the store, which has its own fallback behaviour.
+		if snap, err := ss.Snapshot(ctx); err == nil {
+			return flagship.ContextWithFeatureStore(ctx, snap)
+		}
+	}
+	return flagship.ContextWithFeatureStore(ctx, fs)
+}
+
+func clientContext(ctx context.Context) context.Context {
+	ec := flagship.EvaluationContextFromContext(ctx)
+	var kv []string
+	if ec.TargetingKey != "" {
+		kv = append(kv, TargetingKeyHeader, ec.TargetingKey)
+	}
+	for k, v := range ec.Overrides {
+		kv = append(kv, OverrideHeader, fmt.Sprintf("%s=%t", k, v))
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func evaluationContextFromMetadata(md metadata.MD) flagship.EvaluationContext {
+	var ec flagship.EvaluationContext
+	if v := md.Get(TargetingKeyHeader); len(v) > 0 {
+		ec.TargetingKey = v[0]
+	}
+	for _, o := range md.Get(OverrideHeader) {
+		i := strings.LastIndex(o, "=")
+		if i < 0 {
+			continue
+		}
+		b, err := strconv.ParseBool(o[i+1:])
+		if err != nil {
+			continue
+		}
+		if ec.Overrides == nil {
+			ec.Overrides = make(map[string]bool)
+		}
+		ec.Overrides[o[:i]] = b
+	}
+	return ec
+}
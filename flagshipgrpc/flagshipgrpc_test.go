@@ -0,0 +1,125 @@
+package flagshipgrpc_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/joerdav/flagship"
+	"github.com/joerdav/flagship/flagshipgrpc"
+	"github.com/joerdav/flagship/flagshiptesting"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagation(t *testing.T) {
+	tests := []struct {
+		name     string
+		ec       flagship.EvaluationContext
+		opts     []flagshipgrpc.ServerOption
+		expected flagship.EvaluationContext
+	}{
+		{
+			name: "given no evaluation context, server receives none",
+		},
+		{
+			name:     "given a targeting key, server receives it",
+			ec:       flagship.EvaluationContext{TargetingKey: "user123"},
+			expected: flagship.EvaluationContext{TargetingKey: "user123"},
+		},
+		{
+			name: "given overrides, server ignores them",
+			ec: flagship.EvaluationContext{
+				TargetingKey: "user123",
+				Overrides:    map[string]bool{"newcheckout": true, "search": false},
+			},
+			expected: flagship.EvaluationContext{TargetingKey: "user123"},
+		},
+		{
+			name: "given overrides and a server that trusts them, server receives them",
+			ec: flagship.EvaluationContext{
+				TargetingKey: "user123",
+				Overrides:    map[string]bool{"newcheckout": true, "search": false},
+			},
+			opts: []flagshipgrpc.ServerOption{flagshipgrpc.WithTrustOverrides()},
+			expected: flagship.EvaluationContext{
+				TargetingKey: "user123",
+				Overrides:    map[string]bool{"newcheckout": true, "search": false},
+			},
+		},
+		{
+			name: "given overrides and a server that does not trust the caller, server ignores them",
+			ec: flagship.EvaluationContext{
+				TargetingKey: "user123",
+				Overrides:    map[string]bool{"newcheckout": true},
+			},
+			opts:     []flagshipgrpc.ServerOption{flagshipgrpc.WithTrustOverridesIf(func(context.Context) bool { return false })},
+			expected: flagship.EvaluationContext{TargetingKey: "user123"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flagshiptesting.MockFeatureStore{"newcheckout": true}
+			var sent metadata.MD
+			invoker := func(ctx context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				sent, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			}
+			ctx := flagship.ContextWithEvaluationContext(context.Background(), tt.ec)
+			err := flagshipgrpc.UnaryClientInterceptor()(ctx, "/svc/Method", nil, nil, nil, invoker)
+			if err != nil {
+				t.Fatalf("unexpected error got %v", err)
+			}
+			var received flagship.EvaluationContext
+			var store flagship.FeatureStore
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				received = flagship.EvaluationContextFromContext(ctx)
+				store, _ = flagship.FeatureStoreFromContext(ctx)
+				return nil, nil
+			}
+			serverCtx := metadata.NewIncomingContext(context.Background(), sent)
+			_, err = flagshipgrpc.UnaryServerInterceptor(fs, tt.opts...)(serverCtx, nil, &grpc.UnaryServerInfo{}, handler)
+			if err != nil {
+				t.Fatalf("unexpected error got %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, received); diff != "" {
+				t.Error(diff)
+			}
+			if store == nil || !store.Bool(context.Background(), "newcheckout") {
+				t.Errorf("expected feature store in context")
+			}
+		})
+	}
+}
+
+type mockServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s mockServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	fs := flagshiptesting.MockFeatureStore{}
+	md := metadata.Pairs(flagshipgrpc.TargetingKeyHeader, "user123", flagshipgrpc.OverrideHeader, "search=true")
+	ss := mockServerStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	var received flagship.EvaluationContext
+	handler := func(_ interface{}, stream grpc.ServerStream) error {
+		received = flagship.EvaluationContextFromContext(stream.Context())
+		return nil
+	}
+	err := flagshipgrpc.StreamServerInterceptor(fs, flagshipgrpc.WithTrustOverrides())(nil, ss, &grpc.StreamServerInfo{}, handler)
+	if err != nil {
+		t.Fatalf("unexpected error got %v", err)
+	}
+	expected := flagship.EvaluationContext{
+		TargetingKey: "user123",
+		Overrides:    map[string]bool{"search": true},
+	}
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,113 @@
+package flagship
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math/bits"
+
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// GetHashWithAlgorithm returns the bucket of hashKey for a throttle with the given key using the named algorithm.
+// The key and hash key are hashed together and the result is taken modulo buckets, or 10000 if buckets is 0:
+//
+//	fnv32a  - the 32 bit FNV-1a hash, the default.
+//	murmur3 - the 32 bit MurmurHash3 with a seed of 0.
+//	sha1    - the first 4 bytes of the SHA-1 digest as a big endian integer.
+//
+// Test vectors for each algorithm are published in testdata/hash_vectors.json.
+func GetHashWithAlgorithm(ctx context.Context, algorithm, key string, buckets uint, hashKey io.Reader) (uint, error) {
+	if uint64(buckets) > models.MaxBuckets {
+		return 0, fmt.Errorf("buckets must be at most %d", uint64(models.MaxBuckets))
+	}
+	if buckets == 0 {
+		buckets = models.DefaultBuckets
+	}
+	var h hash.Hash32
+	switch algorithm {
+	case "", "fnv32a":
+		h = fnv.New32a()
+	case "murmur3":
+		h = &murmur3{}
+	case "sha1":
+		h = sha1Hash{sha1.New()}
+	default:
+		return 0, fmt.Errorf("unknown hash algorithm: %s", algorithm)
+	}
+	h.Write([]byte(key))
+	_, _ = io.Copy(h, hashKey)
+	return uint(uint64(h.Sum32()) % uint64(buckets)), nil
+}
+
+// sha1Hash truncates a SHA-1 digest to 32 bits.
+type sha1Hash struct {
+	hash.Hash
+}
+
+func (h sha1Hash) Sum32() uint32 {
+	return binary.BigEndian.Uint32(h.Sum(nil))
+}
+
+// murmur3 is the x86 32 bit variant of MurmurHash3 with a seed of 0.
+// Input is buffered as the final mix depends on the total length.
+type murmur3 struct {
+	buf []byte
+}
+
+func (m *murmur3) Write(p []byte) (int, error) {
+	m.buf = append(m.buf, p...)
+	return len(p), nil
+}
+
+func (m *murmur3) Sum(b []byte) []byte {
+	var s [4]byte
+	binary.BigEndian.PutUint32(s[:], m.Sum32())
+	return append(b, s[:]...)
+}
+
+func (m *murmur3) Reset()         { m.buf = nil }
+func (m *murmur3) Size() int      { return 4 }
+func (m *murmur3) BlockSize() int { return 4 }
+
+func (m *murmur3) Sum32() uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	data := m.buf
+	for len(data) >= 4 {
+		k := binary.LittleEndian.Uint32(data)
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+		data = data[4:]
+	}
+	var k uint32
+	switch len(data) {
+	case 3:
+		k ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(len(m.buf))
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
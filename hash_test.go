@@ -0,0 +1,41 @@
+package flagship
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHashVectors(t *testing.T) {
+	b, err := os.ReadFile("testdata/hash_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Algorithm string `json:"algorithm"`
+		Key       string `json:"key"`
+		Input     string `json:"input"`
+		Buckets   uint   `json:"buckets"`
+		Hash      uint   `json:"hash"`
+	}
+	if err := json.Unmarshal(b, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		h, err := GetHashWithAlgorithm(context.Background(), v.Algorithm, v.Key, v.Buckets, strings.NewReader(v.Input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h != v.Hash {
+			t.Errorf("GetHashWithAlgorithm(%q, %q, %v, %q) = %v, expected %v", v.Algorithm, v.Key, v.Buckets, v.Input, h, v.Hash)
+		}
+	}
+}
+
+func TestUnknownHashAlgorithm(t *testing.T) {
+	if _, err := GetHashWithAlgorithm(context.Background(), "md5", "someFeature", 0, strings.NewReader("an input")); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+}
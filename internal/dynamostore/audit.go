@@ -0,0 +1,464 @@
+package dynamostore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// ErrAuditEntryNotFound is returned when loading a version of a record that has no audit entry.
+var ErrAuditEntryNotFound = errors.New("audit entry not found")
+
+// auditPrefix prefixes the partition keys of audit entries.
+const auditPrefix = "_audit/"
+
+// AuditKey returns the partition key of the audit entry of a version of a record.
+func AuditKey(record string, version int64) string {
+	return auditPrefix + record + "/" + strconv.FormatInt(version, 10)
+}
+
+// AuditEntry is an immutable record of a write to a record, which is put in the same transaction as the write.
+type AuditEntry struct {
+	Record string `json:"record"`
+	// Version is the version of the record after the write.
+	Version    int64     `json:"version"`
+	Actor      string    `json:"actor"`
+	At         time.Time `json:"at"`
+	Reason     string    `json:"reason,omitempty"`
+	BreakGlass string    `json:"breakGlass,omitempty"`
+	// Changes are the features, throttles and incident controls that the write changed.
+	Changes []Change `json:"changes"`
+	// Document is the whole document after the write, it is nil for writes of segments, which are not part of a document.
+	Document *models.StoreDocument `json:"document,omitempty"`
+	// DocumentChunks is the number of items that the document is stored in, compressed, if it is too large to fit in
+	// the entry alongside the changes within the 400KB DynamoDB item size limit. The document is loaded with the entry.
+	// Writes whose changes alone exceed the limit are refused.
+	DocumentChunks int `json:"documentChunks,omitempty"`
+}
+
+// auditChunkKey returns the partition key of a chunk of the document of an audit entry.
+func auditChunkKey(record string, version int64, chunk int) string {
+	return AuditKey(record, version) + "/" + strconv.Itoa(chunk)
+}
+
+// Changed returns whether the write changed the named feature, throttle or control.
+func (e AuditEntry) Changed(name string) bool {
+	for _, c := range e.Changes {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// auditEntry returns the AuditEntry of a write of a version of a record, without its changes.
+func (s *DynamoStore) auditEntry(ctx context.Context, record string, version int64) (AuditEntry, error) {
+	if s.Actor == "" && s.Identify != nil {
+		actor, err := s.Identify(ctx)
+		if err != nil {
+			return AuditEntry{}, fmt.Errorf("failed to identify the actor, pass --actor: %w", err)
+		}
+		s.Actor = actor
+	}
+	e := AuditEntry{
+		Record:     record,
+		Version:    version,
+		Actor:      s.Actor,
+		At:         time.Now().UTC(),
+		Reason:     s.Reason,
+		BreakGlass: s.BreakGlass,
+	}
+	if e.Actor == "" {
+		e.Actor = "unknown"
+	}
+	return e, nil
+}
+
+// auditItems returns the items of the audit entry of a version of the record, with the changes that made it and the document that was written.
+// The first item is the entry, and any others are the chunks of a document too large to be stored in the entry.
+func (s *DynamoStore) auditItems(ctx context.Context, version int64, changes []Change, doc map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	e, err := s.auditEntry(ctx, s.Record, version)
+	if err != nil {
+		return nil, err
+	}
+	e.Changes = changes
+	item, err := marshalMap(e)
+	if err != nil {
+		return nil, err
+	}
+	item["_pk"] = &types.AttributeValueMemberS{Value: AuditKey(s.Record, version)}
+	if size := itemSize(item); size > maxItemSize {
+		return nil, fmt.Errorf("the changes of %s version %d are %d bytes, larger than the DynamoDB item size limit", s.Record, version, size)
+	}
+	document := make(map[string]types.AttributeValue, len(doc))
+	for k, v := range doc {
+		if k != "_pk" {
+			document[k] = v
+		}
+	}
+	item["document"] = &types.AttributeValueMemberM{Value: document}
+	if itemSize(item) <= maxItemSize {
+		return []map[string]types.AttributeValue{item}, nil
+	}
+	delete(item, "document")
+	chunks, err := compressDocument(document)
+	if err != nil {
+		return nil, err
+	}
+	item["documentChunks"] = &types.AttributeValueMemberN{Value: strconv.Itoa(len(chunks))}
+	items := []map[string]types.AttributeValue{item}
+	for i, c := range chunks {
+		items = append(items, map[string]types.AttributeValue{
+			"_pk":  &types.AttributeValueMemberS{Value: auditChunkKey(s.Record, version, i)},
+			"data": &types.AttributeValueMemberB{Value: c},
+		})
+	}
+	return items, nil
+}
+
+// maxAuditChunkSize is the maximum size of a chunk of a compressed document, which leaves room for its key.
+const maxAuditChunkSize = 256 * 1024
+
+// compressDocument encodes a document as compressed JSON, split into chunks of at most maxAuditChunkSize bytes.
+func compressDocument(doc map[string]types.AttributeValue) ([][]byte, error) {
+	var v map[string]interface{}
+	if err := attributevalue.UnmarshalMap(doc, &v); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	c, err := compress(string(b))
+	if err != nil {
+		return nil, err
+	}
+	var chunks [][]byte
+	for len(c) > 0 {
+		n := len(c)
+		if n > maxAuditChunkSize {
+			n = maxAuditChunkSize
+		}
+		chunks = append(chunks, c[:n])
+		c = c[n:]
+	}
+	return chunks, nil
+}
+
+// auditDocument returns the document of an audit entry item, loading it from its chunks if it is stored in them.
+func (s *DynamoStore) auditDocument(ctx context.Context, item map[string]types.AttributeValue) (map[string]types.AttributeValue, bool, error) {
+	if doc, ok := item["document"].(*types.AttributeValueMemberM); ok {
+		return doc.Value, true, nil
+	}
+	var e AuditEntry
+	if err := unmarshalMap(item, &e); err != nil {
+		return nil, false, err
+	}
+	if e.DocumentChunks == 0 {
+		return nil, false, nil
+	}
+	chunks := make([][]byte, e.DocumentChunks)
+	for i := range chunks {
+		gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      &s.TableName,
+			ConsistentRead: aws.Bool(true),
+			Key: map[string]types.AttributeValue{
+				"_pk": &types.AttributeValueMemberS{Value: auditChunkKey(e.Record, e.Version, i)},
+			},
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		b, ok := gio.Item["data"].(*types.AttributeValueMemberB)
+		if !ok {
+			return nil, false, fmt.Errorf("chunk %d of the document of %s version %d is missing", i, e.Record, e.Version)
+		}
+		chunks[i] = b.Value
+	}
+	doc, err := decompressDocument(chunks)
+	return doc, true, err
+}
+
+// decompressDocument decodes a document from the chunks made by compressDocument.
+func decompressDocument(chunks [][]byte) (map[string]types.AttributeValue, error) {
+	r, err := gzip.NewReader(bytes.NewReader(bytes.Join(chunks, nil)))
+	if err != nil {
+		return nil, err
+	}
+	var v map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	return attributevalue.MarshalMap(v)
+}
+
+// decodeAuditEntry decodes an audit entry item, with its document.
+func (s *DynamoStore) decodeAuditEntry(ctx context.Context, item map[string]types.AttributeValue) (AuditEntry, error) {
+	var e AuditEntry
+	if err := unmarshalMap(item, &e); err != nil {
+		return AuditEntry{}, err
+	}
+	if e.Document != nil || e.DocumentChunks == 0 {
+		return e, nil
+	}
+	doc, _, err := s.auditDocument(ctx, item)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	e.Document = new(models.StoreDocument)
+	return e, unmarshalMap(doc, e.Document)
+}
+
+// maxItemSize is the DynamoDB item size limit of 400KB.
+const maxItemSize = 400 * 1024
+
+// itemSize returns the size of an item as DynamoDB calculates it, from the lengths of its attribute names and values.
+func itemSize(item map[string]types.AttributeValue) int {
+	size := 0
+	for k, v := range item {
+		size += len(k) + attributeSize(v)
+	}
+	return size
+}
+
+func attributeSize(v types.AttributeValue) int {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberS:
+		return len(v.Value)
+	case *types.AttributeValueMemberN:
+		// Numbers are stored with up to 38 significant digits, in roughly one byte per two digits.
+		return (len(v.Value)+1)/2 + 1
+	case *types.AttributeValueMemberB:
+		return len(v.Value)
+	case *types.AttributeValueMemberSS:
+		size := 0
+		for _, s := range v.Value {
+			size += len(s)
+		}
+		return size
+	case *types.AttributeValueMemberNS:
+		size := 0
+		for _, n := range v.Value {
+			size += (len(n)+1)/2 + 1
+		}
+		return size
+	case *types.AttributeValueMemberBS:
+		size := 0
+		for _, b := range v.Value {
+			size += len(b)
+		}
+		return size
+	case *types.AttributeValueMemberM:
+		size := 3
+		for k, e := range v.Value {
+			size += len(k) + attributeSize(e) + 1
+		}
+		return size
+	case *types.AttributeValueMemberL:
+		size := 3
+		for _, e := range v.Value {
+			size += attributeSize(e) + 1
+		}
+		return size
+	default:
+		// BOOL and NULL.
+		return 1
+	}
+}
+
+// diffItems returns the changes to features, throttles and incident controls between two versions of a record.
+func diffItems(before, after map[string]types.AttributeValue) []Change {
+	var changes []Change
+	for _, typ := range []string{"feature", "throttle"} {
+		seen := make(map[string]bool)
+		var names []string
+		for _, a := range entryAttributes(typ) {
+			for _, n := range append(mapKeys(before[a]), mapKeys(after[a])...) {
+				if !seen[n] {
+					seen[n] = true
+					names = append(names, n)
+				}
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			o, nw := itemEntry(before, typ, n), itemEntry(after, typ, n)
+			if !reflect.DeepEqual(o.attrs, nw.attrs) {
+				changes = append(changes, Change{Type: typ, Name: n, Old: o.display(), New: nw.display()})
+			}
+		}
+	}
+	for _, a := range []string{"killSwitch", "freeze"} {
+		if !reflect.DeepEqual(before[a], after[a]) {
+			changes = append(changes, Change{Type: "control", Name: a, Old: decodeOptional(before[a]), New: decodeOptional(after[a])})
+		}
+	}
+	return changes
+}
+
+func decodeOptional(av types.AttributeValue) interface{} {
+	if av == nil {
+		return nil
+	}
+	return decode(av)
+}
+
+// LoadAuditEntry returns the audit entry of a version of the record, or ErrAuditEntryNotFound.
+func (s *DynamoStore) LoadAuditEntry(ctx context.Context, version int64) (AuditEntry, error) {
+	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &s.TableName,
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: AuditKey(s.Record, version)},
+		},
+	})
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	if len(gio.Item) < 1 {
+		return AuditEntry{}, fmt.Errorf("%w: %s version %d", ErrAuditEntryNotFound, s.Record, version)
+	}
+	return s.decodeAuditEntry(ctx, gio.Item)
+}
+
+// History returns the audit entries of the record, newest first.
+// If flag is not empty only entries that changed it are returned, and at most limit entries are returned if it is positive.
+// Versions written before auditing was introduced have no entry, and are skipped.
+func (s *DynamoStore) History(ctx context.Context, flag string, limit int) ([]AuditEntry, error) {
+	item, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var entries []AuditEntry
+	for v := itemVersion(item); v > 0; v -= 100 {
+		var versions []int64
+		for i := v; i > 0 && i > v-100; i-- {
+			versions = append(versions, i)
+		}
+		page, err := s.loadAuditEntries(ctx, versions)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			if flag != "" && !e.Changed(flag) {
+				continue
+			}
+			entries = append(entries, e)
+			if limit > 0 && len(entries) == limit {
+				return entries, nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+// loadAuditEntries returns the audit entries of up to 100 versions, newest first, skipping versions without one.
+func (s *DynamoStore) loadAuditEntries(ctx context.Context, versions []int64) ([]AuditEntry, error) {
+	keys := make([]map[string]types.AttributeValue, len(versions))
+	for i, v := range versions {
+		keys[i] = map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: AuditKey(s.Record, v)},
+		}
+	}
+	var entries []AuditEntry
+	request := map[string]types.KeysAndAttributes{
+		s.TableName: {Keys: keys, ConsistentRead: aws.Bool(true)},
+	}
+	for attempt := 1; len(request) > 0; attempt++ {
+		if attempt > 1 {
+			if err := batchBackoff(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+		bgo, err := s.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: request,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range bgo.Responses[s.TableName] {
+			e, err := s.decodeAuditEntry(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		}
+		request = bgo.UnprocessedKeys
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Version > entries[j].Version })
+	return entries, nil
+}
+
+// ErrNoHistory is returned when rebuilding a document at a time before its audit log begins.
+var ErrNoHistory = errors.New("no history")
+
+// LoadDocumentsAt returns the feature documents of several records as they were at a time, from their audit logs.
+func (s *DynamoStore) LoadDocumentsAt(ctx context.Context, at time.Time, records ...string) ([]models.StoreDocument, error) {
+	docs := make([]models.StoreDocument, len(records))
+	for i, r := range records {
+		rs := *s
+		rs.Record = r
+		e, err := rs.auditEntryAt(ctx, at)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = *e.Document
+		if docs[i].Throttles == nil {
+			docs[i].Throttles = make(map[string]models.ThrottleConfig)
+		}
+	}
+	return docs, nil
+}
+
+// auditEntryAt returns the last audit entry of the record with a document written at or before a time.
+// Versions are written in order, so the last version at or before the time is found with a binary search over versions.
+// Versions without an entry, written before auditing was introduced, are older than every entry, and they and entries
+// without a document are skipped by going back to the previous version.
+func (s *DynamoStore) auditEntryAt(ctx context.Context, at time.Time) (AuditEntry, error) {
+	item, err := s.loadItem(ctx)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	lo, hi := int64(1), itemVersion(item)
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		e, err := s.LoadAuditEntry(ctx, mid)
+		if err != nil && !errors.Is(err, ErrAuditEntryNotFound) {
+			return AuditEntry{}, err
+		}
+		if err == nil && e.At.After(at) {
+			hi = mid - 1
+			continue
+		}
+		lo = mid + 1
+	}
+	for v := hi; v > 0; v -= 100 {
+		var versions []int64
+		for i := v; i > 0 && i > v-100; i-- {
+			versions = append(versions, i)
+		}
+		page, err := s.loadAuditEntries(ctx, versions)
+		if err != nil {
+			return AuditEntry{}, err
+		}
+		for _, e := range page {
+			if e.Document != nil {
+				return e, nil
+			}
+		}
+	}
+	return AuditEntry{}, fmt.Errorf("%w of %s at %s", ErrNoHistory, s.Record, at.Format(time.RFC3339))
+}
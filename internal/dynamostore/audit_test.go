@@ -0,0 +1,97 @@
+package dynamostore
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+func TestAuditItems(t *testing.T) {
+	large := strings.Repeat("a", maxItemSize)
+	features := func(values map[string]string) map[string]types.AttributeValue {
+		m := make(map[string]types.AttributeValue)
+		for k, v := range values {
+			m[k] = &types.AttributeValueMemberS{Value: v}
+		}
+		return map[string]types.AttributeValue{
+			"_pk":      &types.AttributeValueMemberS{Value: "features"},
+			"features": &types.AttributeValueMemberM{Value: m},
+		}
+	}
+	tests := []struct {
+		name           string
+		before, after  map[string]types.AttributeValue
+		expectError    bool
+		expectDocument bool
+		expectChunks   int
+	}{
+		{
+			name:           "given a change, the entry has the change and the document",
+			before:         features(nil),
+			after:          features(map[string]string{"newcheckout": "on"}),
+			expectDocument: true,
+		},
+		{
+			name:           "given a large document, the document is stored in chunks",
+			before:         features(map[string]string{"other": large[:300*1024]}),
+			after:          features(map[string]string{"other": large[:300*1024], "newcheckout": large[:50*1024]}),
+			expectDocument: true,
+			expectChunks:   1,
+		},
+		{
+			name:        "given changes larger than an item, the write is refused",
+			before:      features(nil),
+			after:       features(map[string]string{"newcheckout": large}),
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := DynamoStore{Record: "features", Actor: "someone"}
+			items, err := s.auditItems(context.Background(), 1, diffItems(tt.before, tt.after), tt.after)
+			if tt.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var e AuditEntry
+			if err := unmarshalMap(items[0], &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.DocumentChunks != tt.expectChunks || len(items) != tt.expectChunks+1 {
+				t.Fatalf("expected %d chunks, got %d in %d items", tt.expectChunks, e.DocumentChunks, len(items))
+			}
+			if e.DocumentChunks > 0 {
+				var chunks [][]byte
+				for _, item := range items[1:] {
+					chunks = append(chunks, item["data"].(*types.AttributeValueMemberB).Value)
+				}
+				doc, err := decompressDocument(chunks)
+				if err != nil {
+					t.Fatal(err)
+				}
+				e.Document = new(models.StoreDocument)
+				if err := unmarshalMap(doc, e.Document); err != nil {
+					t.Fatal(err)
+				}
+				if e.Document.Features["newcheckout"] != large[:50*1024] {
+					t.Error("expected the document to have newcheckout")
+				}
+			}
+			if len(e.Changes) != 1 || e.Changes[0].Name != "newcheckout" {
+				t.Errorf("expected the change to newcheckout, got %+v", e.Changes)
+			}
+			if (e.Document != nil) != tt.expectDocument {
+				t.Errorf("expected document %v, got %v", tt.expectDocument, e.Document != nil)
+			}
+		})
+	}
+}
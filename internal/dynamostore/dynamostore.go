@@ -3,6 +3,10 @@ package dynamostore
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -10,11 +14,32 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/joerdav/flagship/internal/models"
+	"github.com/joerdav/flagship/internal/schema"
 )
 
+// ErrFrozen is returned when writing to a frozen record without BreakGlass.
+var ErrFrozen = errors.New("record is frozen, pass --break-glass with a reason to override")
+
+// ErrEmptyRecord is returned when loading the document of a record that does not exist.
+var ErrEmptyRecord = errors.New("record is empty")
+
+// ErrVersionMismatch is returned when writing with ExpectVersion set and the record is at a different version.
+var ErrVersionMismatch = errors.New("record version does not match")
+
 type DynamoStore struct {
 	Client            *dynamodb.Client
 	TableName, Record string
+	// BreakGlass is the reason for writing to the record even if it is frozen.
+	BreakGlass string
+	// StrictValidation makes LoadDocuments return a *schema.InvalidDocumentError for documents that do not match the schema.
+	StrictValidation bool
+	// ExpectVersion, if set, is the version the record must be at for a write to be applied.
+	// It is advanced after each successful write, so a sequence of writes fails if another writer interleaves.
+	ExpectVersion *int64
+	// Actor and Reason are recorded in the AuditEntry of each write.
+	Actor, Reason string
+	// Identify, if set, is used to find the Actor the first time it is needed if it is empty.
+	Identify func(ctx context.Context) (string, error)
 }
 
 func NewDynamoStore(tableName, recordName, region string) (DynamoStore, error) {
@@ -39,35 +64,241 @@ func NewDynamoStoreWithClient(tableName, recordName string, client *dynamodb.Cli
 	}
 }
 func (s *DynamoStore) RemoveFeature(ctx context.Context, feature string) error {
-	_, err := s.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		Key: map[string]types.AttributeValue{
-			"_pk": &types.AttributeValueMemberS{Value: s.Record},
-		},
-		TableName:        &s.TableName,
-		UpdateExpression: aws.String("REMOVE features.#f"),
-		ExpressionAttributeNames: map[string]string{
-			"#f": feature,
-		},
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		if m, ok := item["features"].(*types.AttributeValueMemberM); ok {
+			delete(m.Value, feature)
+		}
+		return nil
 	})
-	return err
 }
 func (s *DynamoStore) SetFeature(ctx context.Context, feature string, value bool) error {
-	_, err := s.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		Key: map[string]types.AttributeValue{
-			"_pk": &types.AttributeValueMemberS{Value: s.Record},
-		},
-		TableName:        &s.TableName,
-		UpdateExpression: aws.String("SET features.#f = :c"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":c": &types.AttributeValueMemberBOOL{Value: value},
-		},
-		ExpressionAttributeNames: map[string]string{
-			"#f": feature,
-		},
+	return s.SetFeatureValue(ctx, feature, value)
+}
+
+// SetFeatureValue sets a feature to a value of any type that can be stored in a document:
+// a bool, string, number, or a map or slice of them as decoded from JSON.
+func (s *DynamoStore) SetFeatureValue(ctx context.Context, feature string, value interface{}) error {
+	if value == nil {
+		return errors.New("feature values cannot be null")
+	}
+	av, err := attributevalue.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		mapAttribute(item, "features").Value[feature] = av
+		return nil
+	})
+}
+
+// SetFeatureSchedule enables a feature and sets the window in which it is active.
+// A nil enableAt or disableAt removes that bound.
+func (s *DynamoStore) SetFeatureSchedule(ctx context.Context, feature string, enableAt, disableAt *time.Time) error {
+	bounds := make(map[string]types.AttributeValue)
+	for _, b := range []struct {
+		name string
+		t    *time.Time
+	}{{"enableAt", enableAt}, {"disableAt", disableAt}} {
+		if b.t == nil {
+			continue
+		}
+		av, err := attributevalue.Marshal(*b.t)
+		if err != nil {
+			return err
+		}
+		bounds[b.name] = av
+	}
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		mapAttribute(item, "features").Value[feature] = &types.AttributeValueMemberBOOL{Value: true}
+		config := mapAttribute(item, "featureConfigs", feature)
+		for _, name := range []string{"enableAt", "disableAt"} {
+			if av, ok := bounds[name]; ok {
+				config.Value[name] = av
+			} else {
+				delete(config.Value, name)
+			}
+		}
+		return nil
 	})
-	return err
 }
+
+// mapAttribute returns the map at path in an item, replacing anything else there with an empty map.
+func mapAttribute(item map[string]types.AttributeValue, path ...string) *types.AttributeValueMemberM {
+	m := &types.AttributeValueMemberM{Value: item}
+	for _, p := range path {
+		c, ok := m.Value[p].(*types.AttributeValueMemberM)
+		if !ok {
+			c = &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue)}
+			m.Value[p] = c
+		}
+		m = c
+	}
+	return m
+}
+
+// mutation changes a copy of the item of the record, which is empty if the record does not exist.
+// An error refuses the change.
+type mutation func(item map[string]types.AttributeValue) error
+
+// update applies a versioned change to the record, see versioned.
+// Unless BreakGlass is set, the change is refused with ErrFrozen if the record is frozen.
+func (s *DynamoStore) update(ctx context.Context, change mutation) error {
+	return s.versioned(ctx, change, s.BreakGlass == "")
+}
+
+// maxWriteAttempts is the number of times a versioned write is attempted when other writes interleave.
+const maxWriteAttempts = 5
+
+// maxBatchAttempts is the number of times a batch request is made while DynamoDB leaves some of it unprocessed.
+const maxBatchAttempts = 5
+
+// batchBackoff waits before the given attempt at the unprocessed part of a batch request, doubling the wait from 50ms
+// with each attempt, or returns an error once maxBatchAttempts have been made.
+func batchBackoff(ctx context.Context, attempt int) error {
+	if attempt > maxBatchAttempts {
+		return fmt.Errorf("batch request was not fully processed after %d attempts", maxBatchAttempts)
+	}
+	t := time.NewTimer(time.Duration(25<<(attempt-1)) * time.Millisecond)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// versioned reads the record, applies a change to it and writes the whole record back with its version incremented,
+// conditional on the version being unchanged since it was read, and puts the AuditEntry of the change in the same transaction.
+// If another write interleaves the change is applied again to the new record, up to maxWriteAttempts times before ErrConflict.
+// The change is refused with ErrVersionMismatch if the record is not at ExpectVersion, which is advanced once it succeeds.
+// A change that leaves the record as it was is not written.
+func (s *DynamoStore) versioned(ctx context.Context, change mutation, refuseFrozen bool) error {
+	for attempt := 1; ; attempt++ {
+		before, err := s.loadItem(ctx)
+		if err != nil {
+			return err
+		}
+		version := itemVersion(before)
+		if _, frozen := before["freeze"]; frozen && refuseFrozen {
+			return ErrFrozen
+		}
+		if s.ExpectVersion != nil && version != *s.ExpectVersion {
+			return fmt.Errorf("%w: expected %d, record is at %d", ErrVersionMismatch, *s.ExpectVersion, version)
+		}
+		after := copyItem(before)
+		if err := change(after); err != nil {
+			return err
+		}
+		if reflect.DeepEqual(before, after) || len(before) == 0 && len(after) == 0 {
+			return nil
+		}
+		after["_pk"] = &types.AttributeValueMemberS{Value: s.Record}
+		after["version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(version+1, 10)}
+		audit, err := s.auditItems(ctx, version+1, diffItems(before, after), after)
+		if err != nil {
+			return err
+		}
+		cond := "attribute_not_exists(#version)"
+		var values map[string]types.AttributeValue
+		if version > 0 {
+			cond = "#version = :version"
+			values = map[string]types.AttributeValue{
+				":version": &types.AttributeValueMemberN{Value: strconv.FormatInt(version, 10)},
+			}
+		}
+		items := []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName:                 &s.TableName,
+				Item:                      after,
+				ConditionExpression:       &cond,
+				ExpressionAttributeNames:  map[string]string{"#version": "version"},
+				ExpressionAttributeValues: values,
+			}},
+		}
+		if u := s.registerEnvironment(); u != nil {
+			items = append(items, types.TransactWriteItem{Update: u})
+		}
+		for _, item := range audit {
+			items = append(items, types.TransactWriteItem{Put: &types.Put{
+				TableName:                &s.TableName,
+				Item:                     item,
+				ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+				ExpressionAttributeNames: map[string]string{"#pk": "_pk"},
+			}})
+		}
+		_, err = s.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: items,
+		})
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) && canceledByCondition(tce) {
+			if attempt < maxWriteAttempts {
+				continue
+			}
+			return ErrConflict
+		}
+		if err != nil {
+			return err
+		}
+		if s.ExpectVersion != nil {
+			v := version + 1
+			s.ExpectVersion = &v
+		}
+		return nil
+	}
+}
+
+func canceledByCondition(tce *types.TransactionCanceledException) bool {
+	for _, r := range tce.CancellationReasons {
+		if r.Code != nil && *r.Code == "ConditionalCheckFailed" {
+			return true
+		}
+	}
+	return false
+}
+
+func itemVersion(item map[string]types.AttributeValue) int64 {
+	n, ok := item["version"].(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+	v, _ := strconv.ParseInt(n.Value, 10, 64)
+	return v
+}
+
+// copyItem returns a deep copy of the maps and lists of an item, so that it can be modified.
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	return copyValue(&types.AttributeValueMemberM{Value: item}).(*types.AttributeValueMemberM).Value
+}
+
+func copyValue(v types.AttributeValue) types.AttributeValue {
+	switch v := v.(type) {
+	case *types.AttributeValueMemberM:
+		m := make(map[string]types.AttributeValue, len(v.Value))
+		for k, c := range v.Value {
+			m[k] = copyValue(c)
+		}
+		return &types.AttributeValueMemberM{Value: m}
+	case *types.AttributeValueMemberL:
+		l := make([]types.AttributeValue, len(v.Value))
+		for i, c := range v.Value {
+			l[i] = copyValue(c)
+		}
+		return &types.AttributeValueMemberL{Value: l}
+	}
+	return v
+}
+
 func (s *DynamoStore) Load(ctx context.Context) (models.Features, map[string]models.ThrottleConfig, error) {
+	f, err := s.LoadDocument(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f.Features, f.Throttles, nil
+}
+
+// LoadDocument returns the whole feature document.
+func (s *DynamoStore) LoadDocument(ctx context.Context) (models.StoreDocument, error) {
 	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
 		TableName: &s.TableName,
 		Key: map[string]types.AttributeValue{
@@ -75,22 +306,141 @@ func (s *DynamoStore) Load(ctx context.Context) (models.Features, map[string]mod
 		},
 	})
 	if err != nil {
-		return nil, nil, err
+		return models.StoreDocument{}, err
 	}
 	if len(gio.Item) < 1 {
-		return nil, nil, errors.New("record is empty")
+		return models.StoreDocument{}, ErrEmptyRecord
 	}
 	var f models.StoreDocument
 	err = unmarshalMap(gio.Item, &f)
 	if err != nil {
-		return nil, nil, err
+		return models.StoreDocument{}, err
 	}
 	if f.Throttles == nil {
 		f.Throttles = make(map[string]models.ThrottleConfig)
 	}
-	return f.Features, f.Throttles, nil
+	return f, nil
+}
+
+// LoadDocuments returns the feature documents of several records, in the order given.
+// A single record is loaded with GetItem, and several with a single BatchGetItem.
+// Every record must exist, if one does not an error wrapping ErrEmptyRecord is returned rather than skipping it,
+// so that a misspelt record name is not mistaken for a record without flags.
+func (s *DynamoStore) LoadDocuments(ctx context.Context, records ...string) ([]models.StoreDocument, error) {
+	items, err := s.loadItems(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]models.StoreDocument, len(records))
+	for i, r := range records {
+		item, ok := items[r]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrEmptyRecord, r)
+		}
+		if s.StrictValidation {
+			if err := validateItem(r, item); err != nil {
+				return nil, err
+			}
+		}
+		err := unmarshalMap(item, &docs[i])
+		if err != nil {
+			return nil, err
+		}
+		if docs[i].Throttles == nil {
+			docs[i].Throttles = make(map[string]models.ThrottleConfig)
+		}
+	}
+	return docs, nil
+}
+
+// loadItems returns the items of records that exist, by partition key.
+func (s *DynamoStore) loadItems(ctx context.Context, records []string) (map[string]map[string]types.AttributeValue, error) {
+	items := make(map[string]map[string]types.AttributeValue)
+	if len(records) == 1 {
+		gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName: &s.TableName,
+			Key: map[string]types.AttributeValue{
+				"_pk": &types.AttributeValueMemberS{Value: records[0]},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(gio.Item) > 0 {
+			items[records[0]] = gio.Item
+		}
+		return items, nil
+	}
+	if len(records) > 100 {
+		return nil, errors.New("too many records, the maximum is 100")
+	}
+	var keys []map[string]types.AttributeValue
+	seen := make(map[string]bool)
+	for _, r := range records {
+		// BatchGetItem rejects duplicate keys.
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: r},
+		})
+	}
+	request := map[string]types.KeysAndAttributes{
+		s.TableName: {Keys: keys},
+	}
+	for attempt := 1; len(request) > 0; attempt++ {
+		if attempt > 1 {
+			if err := batchBackoff(ctx, attempt); err != nil {
+				return nil, err
+			}
+		}
+		bgo, err := s.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: request,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range bgo.Responses[s.TableName] {
+			pk, ok := item["_pk"].(*types.AttributeValueMemberS)
+			if ok {
+				items[pk.Value] = item
+			}
+		}
+		request = bgo.UnprocessedKeys
+	}
+	return items, nil
+}
+
+// LoadRawDocument returns the whole feature document, as it would be decoded from JSON, using a consistent read.
+func (s *DynamoStore) LoadRawDocument(ctx context.Context) (map[string]interface{}, error) {
+	item, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(item) < 1 {
+		return nil, ErrEmptyRecord
+	}
+	var doc map[string]interface{}
+	err = attributevalue.UnmarshalMap(item, &doc)
+	return doc, err
+}
+
+func validateItem(record string, item map[string]types.AttributeValue) error {
+	var doc map[string]interface{}
+	if err := attributevalue.UnmarshalMap(item, &doc); err != nil {
+		return err
+	}
+	if errs := schema.Validate(doc); len(errs) > 0 {
+		return &schema.InvalidDocumentError{Record: record, Errors: errs}
+	}
+	return nil
 }
 
 func unmarshalMap(m map[string]types.AttributeValue, out interface{}) error {
 	return attributevalue.NewDecoder(func(do *attributevalue.DecoderOptions) { do.TagKey = "json" }).Decode(&types.AttributeValueMemberM{Value: m}, out)
 }
+
+func marshalMap(in interface{}) (map[string]types.AttributeValue, error) {
+	return attributevalue.MarshalMapWithOptions(in, func(eo *attributevalue.EncoderOptions) { eo.TagKey = "json" })
+}
@@ -0,0 +1,86 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// environmentSeparator separates a record name from its environment in the partition key.
+const environmentSeparator = "#"
+
+// EnvironmentRecord returns the partition key of a record in an environment.
+// The default environment, "", uses the record name as it is.
+func EnvironmentRecord(record, env string) string {
+	if env == "" {
+		return record
+	}
+	return record + environmentSeparator + env
+}
+
+// ValidateEnvironment returns an error if env cannot be used as an environment name.
+func ValidateEnvironment(env string) error {
+	if strings.ContainsAny(env, "#/") {
+		return errors.New("environment names cannot contain '#' or '/'")
+	}
+	return nil
+}
+
+// environmentsPrefix prefixes the partition keys of the registries of the environments of records.
+const environmentsPrefix = "_environments/"
+
+// environmentsKey returns the partition key of the registry of the environments of a record.
+func environmentsKey(record string) string {
+	return environmentsPrefix + record
+}
+
+// splitEnvironmentRecord returns the record name and environment of a partition key made by EnvironmentRecord.
+func splitEnvironmentRecord(pk string) (record, env string, ok bool) {
+	i := strings.LastIndex(pk, environmentSeparator)
+	if i < 0 {
+		return pk, "", false
+	}
+	return pk[:i], pk[i+len(environmentSeparator):], true
+}
+
+// registerEnvironment returns the write that adds the environment of the record to the registry of the environments
+// of its record name, or nil for the default environment. It is written with every write of the record, so records
+// written before the registry was introduced are registered by their next write.
+func (s *DynamoStore) registerEnvironment() *types.Update {
+	record, env, ok := splitEnvironmentRecord(s.Record)
+	if !ok {
+		return nil
+	}
+	return &types.Update{
+		TableName:                &s.TableName,
+		Key:                      map[string]types.AttributeValue{"_pk": &types.AttributeValueMemberS{Value: environmentsKey(record)}},
+		UpdateExpression:         aws.String("ADD #environments :env"),
+		ExpressionAttributeNames: map[string]string{"#environments": "environments"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":env": &types.AttributeValueMemberSS{Value: []string{env}},
+		},
+	}
+}
+
+// ListEnvironments returns the environments that have a record with the given name, in order.
+// The default environment is returned as "" if the record exists.
+// Other environments are read from a registry that each write of an environment's record adds it to.
+func (s *DynamoStore) ListEnvironments(ctx context.Context, record string) ([]string, error) {
+	items, err := s.loadItems(ctx, []string{record, environmentsKey(record)})
+	if err != nil {
+		return nil, err
+	}
+	var envs []string
+	if _, ok := items[record]; ok {
+		envs = append(envs, "")
+	}
+	if ss, ok := items[environmentsKey(record)]["environments"].(*types.AttributeValueMemberSS); ok {
+		envs = append(envs, ss.Value...)
+	}
+	sort.Strings(envs)
+	return envs, nil
+}
@@ -0,0 +1,41 @@
+package dynamostore
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// Freeze marks the record as frozen, so that writes are refused unless BreakGlass is set.
+func (s *DynamoStore) Freeze(ctx context.Context, reason string, at time.Time) error {
+	av, err := attributevalue.MarshalWithOptions(models.Freeze{Reason: reason, At: at}, func(eo *attributevalue.EncoderOptions) { eo.TagKey = "json" })
+	if err != nil {
+		return err
+	}
+	return s.controlUpdate(ctx, "freeze", av)
+}
+
+// Unfreeze removes the freeze marker from the record.
+func (s *DynamoStore) Unfreeze(ctx context.Context) error {
+	return s.controlUpdate(ctx, "freeze", nil)
+}
+
+// SetKillSwitch turns the kill switch of the record on or off.
+func (s *DynamoStore) SetKillSwitch(ctx context.Context, value bool) error {
+	return s.controlUpdate(ctx, "killSwitch", &types.AttributeValueMemberBOOL{Value: value})
+}
+
+// controlUpdate sets, or removes if value is nil, an incident control with a versioned write, which is allowed even when the record is frozen.
+func (s *DynamoStore) controlUpdate(ctx context.Context, attribute string, value types.AttributeValue) error {
+	return s.versioned(ctx, func(item map[string]types.AttributeValue) error {
+		if value == nil {
+			delete(item, attribute)
+		} else {
+			item[attribute] = value
+		}
+		return nil
+	}, false)
+}
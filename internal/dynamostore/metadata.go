@@ -0,0 +1,48 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// ErrFeatureNotFound is returned when modifying a feature that does not exist.
+var ErrFeatureNotFound = errors.New("feature not found")
+
+// SetFeatureMetadata replaces the metadata of an existing feature.
+func (s *DynamoStore) SetFeatureMetadata(ctx context.Context, feature string, m models.Metadata) error {
+	av, err := marshalMetadata(m)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		if _, ok := mapValue(item["features"], feature); !ok {
+			return ErrFeatureNotFound
+		}
+		mapAttribute(item, "featureConfigs", feature).Value["metadata"] = av
+		return nil
+	})
+}
+
+// SetThrottleMetadata replaces the metadata of an existing throttle.
+func (s *DynamoStore) SetThrottleMetadata(ctx context.Context, throttle string, m models.Metadata) error {
+	av, err := marshalMetadata(m)
+	if err != nil {
+		return err
+	}
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		t, ok := throttleAttribute(item, throttle)
+		if !ok {
+			return ErrThrottleNotFound
+		}
+		t.Value["metadata"] = av
+		return nil
+	})
+}
+
+func marshalMetadata(m models.Metadata) (types.AttributeValue, error) {
+	return attributevalue.MarshalWithOptions(m, func(eo *attributevalue.EncoderOptions) { eo.TagKey = "json" })
+}
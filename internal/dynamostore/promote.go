@@ -0,0 +1,275 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrConflict is returned when a record has changed since a write was planned.
+var ErrConflict = errors.New("record has changed since the plan was made")
+
+// Change is a difference in a single feature, throttle or incident control.
+type Change struct {
+	// Type is either "feature" or "throttle", or in audit entries "control" for the kill switch and freeze, or "segment".
+	Type string `json:"type"`
+	Name string `json:"name"`
+	// Old and New are the entry before and after the change, nil if it does not exist.
+	// For features this includes the value and its feature config.
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// entry is a feature or throttle as it is stored, including the attributes that make up the entry.
+type entry struct {
+	typ, name string
+	// attrs maps a top level attribute, e.g. "features", to the value of the entry within it.
+	attrs map[string]types.AttributeValue
+}
+
+func (e entry) display() interface{} {
+	if len(e.attrs) == 0 {
+		return nil
+	}
+	if e.typ == "throttle" {
+		return decode(e.attrs["throttles"])
+	}
+	v := map[string]interface{}{"value": decode(e.attrs["features"])}
+	if c, ok := e.attrs["featureConfigs"]; ok {
+		v["config"] = decode(c)
+	}
+	return v
+}
+
+func decode(av types.AttributeValue) interface{} {
+	var v interface{}
+	_ = attributevalue.Unmarshal(av, &v)
+	return v
+}
+
+// Promotion is a planned copy of features and throttles from one record, or version of a record, to another.
+type Promotion struct {
+	Changes []Change
+	// old and new are the top level map attributes of the target record before and after the promotion.
+	old, new map[string]types.AttributeValue
+}
+
+// promotedAttributes are the top level attributes of a record that hold features and throttles.
+var promotedAttributes = []string{"features", "featureConfigs", "throttles"}
+
+// PlanPromotion compares the named features and throttles of the from record with this record.
+// If no names are given then every feature and throttle of the from record is compared.
+func (s *DynamoStore) PlanPromotion(ctx context.Context, from string, names []string) (*Promotion, error) {
+	source := *s
+	source.Record = from
+	src, err := source.loadItem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", from, err)
+	}
+	if len(src) < 1 {
+		return nil, fmt.Errorf("record is empty: %s", from)
+	}
+	dst, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", s.Record, err)
+	}
+	if len(names) == 0 {
+		names = append(mapKeys(src["features"]), mapKeys(src["throttles"])...)
+	}
+	for _, n := range names {
+		if entryType(n, src) == "" {
+			return nil, fmt.Errorf("%s does not exist in %s", n, from)
+		}
+	}
+	return planPromotion(src, dst, names), nil
+}
+
+// PlanApply plans making the features and throttles of the record match a document decoded from JSON.
+// Features and throttles that are not in the document are removed.
+// The version, kill switch and freeze of the document are not applied.
+func (s *DynamoStore) PlanApply(ctx context.Context, doc map[string]interface{}) (*Promotion, error) {
+	src, err := attributevalue.MarshalMap(doc)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", s.Record, err)
+	}
+	return planPromotion(src, dst, entryNames(src, dst)), nil
+}
+
+// DiffDocuments returns the differences in features and throttles between two documents decoded from JSON, ordered by type and name.
+// Old is the entry in a, and New the entry in b.
+func DiffDocuments(a, b map[string]interface{}) ([]Change, error) {
+	ai, err := attributevalue.MarshalMap(a)
+	if err != nil {
+		return nil, err
+	}
+	bi, err := attributevalue.MarshalMap(b)
+	if err != nil {
+		return nil, err
+	}
+	changes := planPromotion(bi, ai, entryNames(ai, bi)).Changes
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return changes[i].Type < changes[j].Type
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// entryNames returns the names of the features and throttles in any of the items.
+func entryNames(items ...map[string]types.AttributeValue) []string {
+	var names []string
+	for _, item := range items {
+		names = append(names, mapKeys(item["features"])...)
+		names = append(names, mapKeys(item["throttles"])...)
+	}
+	return names
+}
+
+// planPromotion returns the promotion that makes the named entries of dst the same as in src.
+// Entries that are not in src are removed from dst.
+func planPromotion(src, dst map[string]types.AttributeValue, names []string) *Promotion {
+	p := Promotion{
+		old: make(map[string]types.AttributeValue),
+		new: make(map[string]types.AttributeValue),
+	}
+	for _, a := range promotedAttributes {
+		if v, ok := dst[a]; ok {
+			p.old[a] = v
+		}
+		p.new[a] = copyMap(dst[a])
+	}
+	seen := make(map[string]bool)
+	for _, n := range names {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		typ := entryType(n, src, dst)
+		if typ == "" {
+			continue
+		}
+		o, nw := itemEntry(dst, typ, n), itemEntry(src, typ, n)
+		if reflect.DeepEqual(o.attrs, nw.attrs) {
+			continue
+		}
+		for _, a := range entryAttributes(typ) {
+			m := p.new[a].(*types.AttributeValueMemberM)
+			if v, ok := nw.attrs[a]; ok {
+				m.Value[n] = v
+			} else {
+				delete(m.Value, n)
+			}
+		}
+		p.Changes = append(p.Changes, Change{Type: typ, Name: n, Old: o.display(), New: nw.display()})
+	}
+	return &p
+}
+
+// entryType returns the type of the named entry in the first item that has it, or "" if none do.
+func entryType(name string, items ...map[string]types.AttributeValue) string {
+	for _, item := range items {
+		if _, ok := mapValue(item["features"], name); ok {
+			return "feature"
+		}
+		if _, ok := mapValue(item["throttles"], name); ok {
+			return "throttle"
+		}
+	}
+	return ""
+}
+
+// ApplyPromotion writes a promotion in a single versioned write.
+// If the features or throttles of the record have been modified since the promotion was planned then ErrConflict is returned.
+func (s *DynamoStore) ApplyPromotion(ctx context.Context, p *Promotion) error {
+	if len(p.Changes) == 0 {
+		return nil
+	}
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		for _, a := range promotedAttributes {
+			if !reflect.DeepEqual(item[a], p.old[a]) {
+				return ErrConflict
+			}
+		}
+		for _, a := range promotedAttributes {
+			item[a] = copyValue(p.new[a])
+		}
+		return nil
+	})
+}
+
+func (s *DynamoStore) loadItem(ctx context.Context) (map[string]types.AttributeValue, error) {
+	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &s.TableName,
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: s.Record},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gio.Item, nil
+}
+
+// entryAttributes returns the top level attributes that make up an entry of the given type.
+func entryAttributes(typ string) []string {
+	if typ == "feature" {
+		return []string{"features", "featureConfigs"}
+	}
+	return []string{"throttles"}
+}
+
+func itemEntry(item map[string]types.AttributeValue, typ, name string) entry {
+	e := entry{typ: typ, name: name, attrs: make(map[string]types.AttributeValue)}
+	for _, a := range entryAttributes(typ) {
+		if v, ok := mapValue(item[a], name); ok {
+			e.attrs[a] = v
+		}
+	}
+	return e
+}
+
+func mapValue(av types.AttributeValue, key string) (types.AttributeValue, bool) {
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, false
+	}
+	v, ok := m.Value[key]
+	return v, ok
+}
+
+// copyMap returns a shallow copy of a map attribute, or an empty map if av is not one.
+func copyMap(av types.AttributeValue) *types.AttributeValueMemberM {
+	c := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue)}
+	if m, ok := av.(*types.AttributeValueMemberM); ok {
+		for k, v := range m.Value {
+			c.Value[k] = v
+		}
+	}
+	return c
+}
+
+func mapKeys(av types.AttributeValue) []string {
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	for k := range m.Value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
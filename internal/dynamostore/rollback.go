@@ -0,0 +1,111 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PlanRollback plans restoring the features and throttles of the record to how they were at a version, from its audit entry.
+// Features and throttles added since that version are removed. The kill switch and freeze are not rolled back.
+func (s *DynamoStore) PlanRollback(ctx context.Context, version int64) (*Promotion, error) {
+	src, err := s.loadAuditDocument(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", s.Record, err)
+	}
+	return planPromotion(src, dst, entryNames(src, dst)), nil
+}
+
+// PlanFlagRollback plans restoring a feature or throttle to how it was before the last steps changes to it in the audit log.
+func (s *DynamoStore) PlanFlagRollback(ctx context.Context, flag string, steps int) (*Promotion, error) {
+	if steps < 1 {
+		return nil, errors.New("steps must be at least 1")
+	}
+	entries, err := s.History(ctx, flag, steps)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) < steps {
+		return nil, fmt.Errorf("%s has only %d changes in the audit log", flag, len(entries))
+	}
+	var change Change
+	for _, ch := range entries[steps-1].Changes {
+		if ch.Name == flag {
+			change = ch
+		}
+	}
+	if change.Type != "feature" && change.Type != "throttle" {
+		return nil, fmt.Errorf("%s is not a feature or throttle, only features and throttles can be rolled back", flag)
+	}
+	src, err := changeItem(change.Type, flag, change.Old)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := s.loadItem(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", s.Record, err)
+	}
+	return planPromotion(src, dst, []string{flag}), nil
+}
+
+// changeItem returns an item holding only the entry of a change's Old or New value, the inverse of entry.display.
+// The item is empty if the value is nil, as the entry did not exist.
+func changeItem(typ, name string, v interface{}) (map[string]types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue)
+	if v == nil {
+		return item, nil
+	}
+	values := map[string]interface{}{"throttles": v}
+	if typ == "feature" {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid feature in audit log: %v", v)
+		}
+		values = map[string]interface{}{"features": m["value"]}
+		if c, ok := m["config"]; ok {
+			values["featureConfigs"] = c
+		}
+	}
+	for a, v := range values {
+		av, err := attributevalue.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		item[a] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{name: av}}
+	}
+	return item, nil
+}
+
+// loadAuditDocument returns the document of the record at a version, as it is stored in the audit entry of the version or its chunks.
+func (s *DynamoStore) loadAuditDocument(ctx context.Context, version int64) (map[string]types.AttributeValue, error) {
+	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      &s.TableName,
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: AuditKey(s.Record, version)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(gio.Item) < 1 {
+		return nil, fmt.Errorf("%w: %s version %d", ErrAuditEntryNotFound, s.Record, version)
+	}
+	doc, ok, err := s.auditDocument(ctx, gio.Item)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("the audit entry of %s version %d has no document", s.Record, version)
+	}
+	return doc, nil
+}
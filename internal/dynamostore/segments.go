@@ -0,0 +1,333 @@
+package dynamostore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// ErrSegmentNotFound is returned when loading a segment that does not exist.
+var ErrSegmentNotFound = errors.New("segment not found")
+
+// segmentPrefix prefixes the partition keys of segments, which are shared by every record in the table.
+const segmentPrefix = "_segment/"
+
+// maxSegmentChunkSize is the maximum size of the members of a chunk before compression,
+// which keeps each chunk well within the DynamoDB item size limit.
+const maxSegmentChunkSize = 256 * 1024
+
+// SegmentKey returns the partition key of a segment's manifest.
+func SegmentKey(name string) string {
+	return segmentPrefix + name
+}
+
+func segmentChunkKey(name, generation string, chunk int) string {
+	return SegmentKey(name) + "/" + generation + "/" + strconv.Itoa(chunk)
+}
+
+// ValidateSegment returns an error if name cannot be used as a segment name.
+func ValidateSegment(name string) error {
+	if name == "" || strings.Contains(name, "/") {
+		return errors.New("segment names must not be empty or contain '/'")
+	}
+	return nil
+}
+
+// PutSegment replaces the members of a segment.
+// Members are sorted and split into chunks that are written before the manifest, so readers never see a partial segment.
+// The chunks of the previous generation are then deleted, readers that still hold its manifest reload it when a chunk is missing.
+// The manifest is written as writeSegmentManifest describes, so the write is refused if the record is frozen and is audited.
+func (s *DynamoStore) PutSegment(ctx context.Context, name string, members []string) (models.SegmentManifest, error) {
+	if err := ValidateSegment(name); err != nil {
+		return models.SegmentManifest{}, err
+	}
+	chunks, err := splitSegment(members)
+	if err != nil {
+		return models.SegmentManifest{}, err
+	}
+	m := models.SegmentManifest{Generation: uuid.NewString()}
+	for _, c := range chunks {
+		m.Chunks = append(m.Chunks, c[0])
+		m.Count += len(c)
+	}
+	var writes []types.WriteRequest
+	for i, c := range chunks {
+		b, err := compress(strings.Join(c, "\n"))
+		if err != nil {
+			return models.SegmentManifest{}, err
+		}
+		writes = append(writes, types.WriteRequest{PutRequest: &types.PutRequest{Item: map[string]types.AttributeValue{
+			"_pk":     &types.AttributeValueMemberS{Value: segmentChunkKey(name, m.Generation, i)},
+			"members": &types.AttributeValueMemberB{Value: b},
+		}}})
+	}
+	if err := s.batchWrite(ctx, writes); err != nil {
+		return models.SegmentManifest{}, err
+	}
+	old, err := s.writeSegmentManifest(ctx, name, &m)
+	if err != nil {
+		// The chunks of the new generation are not referenced by any manifest.
+		if derr := s.deleteSegmentChunks(ctx, name, m); derr != nil {
+			return models.SegmentManifest{}, fmt.Errorf("%w, and failed to delete its chunks: %v", err, derr)
+		}
+		return models.SegmentManifest{}, err
+	}
+	return m, s.deleteSegmentChunks(ctx, name, old)
+}
+
+// splitSegment sorts and deduplicates members, ignoring empty ones,
+// and splits them into chunks of at most maxSegmentChunkSize bytes.
+func splitSegment(members []string) ([][]string, error) {
+	sorted := make([]string, 0, len(members))
+	for _, m := range members {
+		if strings.Contains(m, "\n") {
+			return nil, fmt.Errorf("segment members cannot contain a newline: %q", m)
+		}
+		if m != "" {
+			sorted = append(sorted, m)
+		}
+	}
+	sort.Strings(sorted)
+	var chunks [][]string
+	size := 0
+	for i, id := range sorted {
+		if i > 0 && id == sorted[i-1] {
+			continue
+		}
+		if len(chunks) == 0 || size+len(id)+1 > maxSegmentChunkSize {
+			chunks = append(chunks, nil)
+			size = 0
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], id)
+		size += len(id) + 1
+	}
+	return chunks, nil
+}
+
+// RemoveSegment removes a segment and all of its chunks.
+func (s *DynamoStore) RemoveSegment(ctx context.Context, name string) error {
+	m, err := s.writeSegmentManifest(ctx, name, nil)
+	if err != nil {
+		return err
+	}
+	return s.deleteSegmentChunks(ctx, name, m)
+}
+
+// writeSegmentManifest puts the manifest of a segment, or deletes it if m is nil, and returns the manifest it replaced.
+// The manifest is versioned like a record, and the AuditEntry of the change is put under AuditKey(SegmentKey(name), version) in the same transaction.
+// Segments are shared by every record in the table, but unless BreakGlass is set the write is refused with ErrFrozen if the record of the store is frozen.
+func (s *DynamoStore) writeSegmentManifest(ctx context.Context, name string, m *models.SegmentManifest) (models.SegmentManifest, error) {
+	key := map[string]types.AttributeValue{
+		"_pk": &types.AttributeValueMemberS{Value: SegmentKey(name)},
+	}
+	for attempt := 1; ; attempt++ {
+		gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      &s.TableName,
+			ConsistentRead: aws.Bool(true),
+			Key:            key,
+		})
+		if err != nil {
+			return models.SegmentManifest{}, err
+		}
+		var old models.SegmentManifest
+		if err := unmarshalMap(gio.Item, &old); err != nil {
+			return models.SegmentManifest{}, err
+		}
+		if m == nil && len(gio.Item) < 1 {
+			return models.SegmentManifest{}, fmt.Errorf("%w: %s", ErrSegmentNotFound, name)
+		}
+		version := itemVersion(gio.Item)
+		e, err := s.auditEntry(ctx, SegmentKey(name), version+1)
+		if err != nil {
+			return models.SegmentManifest{}, err
+		}
+		change := Change{Type: "segment", Name: name}
+		if len(gio.Item) > 0 {
+			change.Old = old
+		}
+		if m != nil {
+			m.Version = version + 1
+			change.New = *m
+		}
+		e.Changes = []Change{change}
+		entry, err := marshalMap(e)
+		if err != nil {
+			return models.SegmentManifest{}, err
+		}
+		entry["_pk"] = &types.AttributeValueMemberS{Value: AuditKey(SegmentKey(name), version+1)}
+		cond := "attribute_not_exists(#version)"
+		values := map[string]types.AttributeValue{}
+		if version > 0 {
+			cond = "#version = :version"
+			values[":version"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(version, 10)}
+		}
+		var items []types.TransactWriteItem
+		if s.BreakGlass == "" {
+			items = append(items, types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+				TableName: &s.TableName,
+				Key: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: s.Record},
+				},
+				ConditionExpression:      aws.String("attribute_not_exists(#freeze)"),
+				ExpressionAttributeNames: map[string]string{"#freeze": "freeze"},
+			}})
+		}
+		if m != nil {
+			item, err := marshalMap(*m)
+			if err != nil {
+				return models.SegmentManifest{}, err
+			}
+			item["_pk"] = key["_pk"]
+			items = append(items, types.TransactWriteItem{Put: &types.Put{
+				TableName:                 &s.TableName,
+				Item:                      item,
+				ConditionExpression:       &cond,
+				ExpressionAttributeNames:  map[string]string{"#version": "version"},
+				ExpressionAttributeValues: nilIfEmpty(values),
+			}})
+		} else {
+			items = append(items, types.TransactWriteItem{Delete: &types.Delete{
+				TableName:                 &s.TableName,
+				Key:                       key,
+				ConditionExpression:       &cond,
+				ExpressionAttributeNames:  map[string]string{"#version": "version"},
+				ExpressionAttributeValues: nilIfEmpty(values),
+			}})
+		}
+		items = append(items, types.TransactWriteItem{Put: &types.Put{
+			TableName:                &s.TableName,
+			Item:                     entry,
+			ConditionExpression:      aws.String("attribute_not_exists(#pk)"),
+			ExpressionAttributeNames: map[string]string{"#pk": "_pk"},
+		}})
+		_, err = s.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+		var tce *types.TransactionCanceledException
+		if errors.As(err, &tce) && canceledByCondition(tce) {
+			if s.BreakGlass == "" && len(tce.CancellationReasons) > 0 && aws.ToString(tce.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+				return models.SegmentManifest{}, ErrFrozen
+			}
+			if attempt < maxWriteAttempts {
+				continue
+			}
+			return models.SegmentManifest{}, fmt.Errorf("segment %s is being changed by another writer: %w", name, err)
+		}
+		if err != nil {
+			return models.SegmentManifest{}, err
+		}
+		return old, nil
+	}
+}
+
+// nilIfEmpty returns nil for an empty map of expression values, which DynamoDB rejects.
+func nilIfEmpty(values map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// LoadSegmentManifest returns the manifest of a segment, or ErrSegmentNotFound.
+func (s *DynamoStore) LoadSegmentManifest(ctx context.Context, name string) (models.SegmentManifest, error) {
+	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &s.TableName,
+		Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: SegmentKey(name)},
+		},
+	})
+	if err != nil {
+		return models.SegmentManifest{}, err
+	}
+	if len(gio.Item) < 1 {
+		return models.SegmentManifest{}, fmt.Errorf("%w: %s", ErrSegmentNotFound, name)
+	}
+	var m models.SegmentManifest
+	err = unmarshalMap(gio.Item, &m)
+	return m, err
+}
+
+// LoadSegmentChunk returns the sorted members of a single chunk of a segment.
+func (s *DynamoStore) LoadSegmentChunk(ctx context.Context, name, generation string, chunk int) ([]string, error) {
+	gio, err := s.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: &s.TableName,
+		Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: segmentChunkKey(name, generation, chunk)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, ok := gio.Item["members"].(*types.AttributeValueMemberB)
+	if !ok {
+		return nil, fmt.Errorf("%w: chunk %d of %s", ErrSegmentNotFound, chunk, name)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(b.Value))
+	if err != nil {
+		return nil, err
+	}
+	members, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(string(members), "\n"), nil
+}
+
+func (s *DynamoStore) deleteSegmentChunks(ctx context.Context, name string, m models.SegmentManifest) error {
+	var deletes []types.WriteRequest
+	for i := range m.Chunks {
+		deletes = append(deletes, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+			"_pk": &types.AttributeValueMemberS{Value: segmentChunkKey(name, m.Generation, i)},
+		}}})
+	}
+	return s.batchWrite(ctx, deletes)
+}
+
+// batchWrite writes requests in batches of 25, retrying any that are unprocessed.
+func (s *DynamoStore) batchWrite(ctx context.Context, writes []types.WriteRequest) error {
+	for len(writes) > 0 {
+		n := len(writes)
+		if n > 25 {
+			n = 25
+		}
+		request := map[string][]types.WriteRequest{s.TableName: writes[:n]}
+		writes = writes[n:]
+		for attempt := 1; len(request) > 0; attempt++ {
+			if attempt > 1 {
+				if err := batchBackoff(ctx, attempt); err != nil {
+					return err
+				}
+			}
+			out, err := s.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: request,
+			})
+			if err != nil {
+				return err
+			}
+			request = out.UnprocessedItems
+		}
+	}
+	return nil
+}
+
+func compress(s string) ([]byte, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := io.WriteString(w, s); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,121 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrTableNotFound is returned when the table does not exist.
+var ErrTableNotFound = errors.New("table not found")
+
+// ErrTableExists is returned when creating a table that already exists.
+var ErrTableExists = errors.New("table already exists")
+
+// ErrRecordExists is returned when initialising a record that already exists.
+var ErrRecordExists = errors.New("record already exists")
+
+// CreateTable creates the table with the _pk string hash key, billed per request, and waits up to wait for it to become active.
+func (s *DynamoStore) CreateTable(ctx context.Context, wait time.Duration) error {
+	_, err := s.Client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: &s.TableName,
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("_pk"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("_pk"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	var riu *types.ResourceInUseException
+	if errors.As(err, &riu) {
+		return ErrTableExists
+	}
+	if err != nil {
+		return err
+	}
+	return dynamodb.NewTableExistsWaiter(s.Client).Wait(ctx, &dynamodb.DescribeTableInput{TableName: &s.TableName}, wait)
+}
+
+// DescribeTable returns the description of the table, or ErrTableNotFound.
+func (s *DynamoStore) DescribeTable(ctx context.Context) (*types.TableDescription, error) {
+	out, err := s.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &s.TableName})
+	var rnf *types.ResourceNotFoundException
+	if errors.As(err, &rnf) {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, s.TableName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Table, nil
+}
+
+// CheckKeySchema returns an error unless a table has only a string hash key named _pk, which records are stored under.
+func CheckKeySchema(t *types.TableDescription) error {
+	if len(t.KeySchema) != 1 || aws.ToString(t.KeySchema[0].AttributeName) != "_pk" || t.KeySchema[0].KeyType != types.KeyTypeHash {
+		var keys []string
+		for _, k := range t.KeySchema {
+			keys = append(keys, fmt.Sprintf("%s (%s)", aws.ToString(k.AttributeName), k.KeyType))
+		}
+		return fmt.Errorf("the key must be a single _pk hash key, got %v", keys)
+	}
+	for _, a := range t.AttributeDefinitions {
+		if aws.ToString(a.AttributeName) == "_pk" && a.AttributeType != types.ScalarAttributeTypeS {
+			return fmt.Errorf("_pk must be a string, got type %s", a.AttributeType)
+		}
+	}
+	return nil
+}
+
+// InitRecord creates the record as an empty document, or returns ErrRecordExists.
+func (s *DynamoStore) InitRecord(ctx context.Context) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		if len(item) > 0 {
+			return ErrRecordExists
+		}
+		item["features"] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+		item["throttles"] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{}}
+		return nil
+	})
+}
+
+// CheckWriteAccess returns an error if the credentials in use cannot write to the record and its audit log.
+// It makes a write of the same kind as every change, with a condition that cannot be met, so nothing is changed.
+// Permission is checked before the condition, so a failed condition means that the write is allowed.
+func (s *DynamoStore) CheckWriteAccess(ctx context.Context) error {
+	never := aws.String("attribute_exists(#pk) AND attribute_not_exists(#pk)")
+	_, err := s.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName: &s.TableName,
+				Item: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: s.Record},
+				},
+				ConditionExpression:      never,
+				ExpressionAttributeNames: map[string]string{"#pk": "_pk"},
+			}},
+			{Put: &types.Put{
+				TableName: &s.TableName,
+				// Versions start at 1, so there is never an audit entry for version 0.
+				Item: map[string]types.AttributeValue{
+					"_pk": &types.AttributeValueMemberS{Value: AuditKey(s.Record, 0)},
+				},
+				ConditionExpression:      never,
+				ExpressionAttributeNames: map[string]string{"#pk": "_pk"},
+			}},
+		},
+	})
+	var tce *types.TransactionCanceledException
+	if errors.As(err, &tce) && canceledByCondition(tce) {
+		return nil
+	}
+	if err == nil {
+		return errors.New("the write check was unexpectedly applied")
+	}
+	return err
+}
@@ -0,0 +1,206 @@
+package dynamostore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// ErrThrottleNotFound is returned when modifying a throttle that does not exist.
+var ErrThrottleNotFound = errors.New("throttle not found")
+
+// SetThrottleProbability sets the probability of an existing throttle.
+func (s *DynamoStore) SetThrottleProbability(ctx context.Context, throttle string, probability float64) error {
+	return s.setThrottleAttribute(ctx, throttle, "probability", &types.AttributeValueMemberN{Value: strconv.FormatFloat(probability, 'f', -1, 64)})
+}
+
+// SetThrottleForceRejectAll sets forceRejectAll on an existing throttle.
+func (s *DynamoStore) SetThrottleForceRejectAll(ctx context.Context, throttle string, value bool) error {
+	return s.setThrottleAttribute(ctx, throttle, "forceRejectAll", &types.AttributeValueMemberBOOL{Value: value})
+}
+
+// ErrThrottleExists is returned when creating a throttle that already exists.
+var ErrThrottleExists = errors.New("throttle already exists")
+
+// CreateThrottle creates a throttle with a probability.
+func (s *DynamoStore) CreateThrottle(ctx context.Context, throttle string, probability float64) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		throttles := mapAttribute(item, "throttles")
+		if _, ok := throttles.Value[throttle]; ok {
+			return ErrThrottleExists
+		}
+		throttles.Value[throttle] = &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"probability": &types.AttributeValueMemberN{Value: strconv.FormatFloat(probability, 'f', -1, 64)},
+		}}
+		return nil
+	})
+}
+
+// RemoveThrottle removes an existing throttle.
+func (s *DynamoStore) RemoveThrottle(ctx context.Context, throttle string) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		if _, ok := throttleAttribute(item, throttle); !ok {
+			return ErrThrottleNotFound
+		}
+		delete(item["throttles"].(*types.AttributeValueMemberM).Value, throttle)
+		return nil
+	})
+}
+
+func (s *DynamoStore) setThrottleAttribute(ctx context.Context, throttle, attribute string, value types.AttributeValue) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		t, ok := throttleAttribute(item, throttle)
+		if !ok {
+			return ErrThrottleNotFound
+		}
+		t.Value[attribute] = value
+		return nil
+	})
+}
+
+// throttleAttribute returns the map of a throttle in an item.
+func throttleAttribute(item map[string]types.AttributeValue, throttle string) (*types.AttributeValueMemberM, bool) {
+	v, ok := mapValue(item["throttles"], throttle)
+	if !ok {
+		return nil, false
+	}
+	t, ok := v.(*types.AttributeValueMemberM)
+	return t, ok
+}
+
+// ErrListEntryNotFound is returned when removing an identifier that is not in a throttle's list.
+var ErrListEntryNotFound = errors.New("identifier not found in list")
+
+// ThrottleLists are the lists of a throttle that can be modified with AddThrottleListEntry and RemoveThrottleListEntry.
+var ThrottleLists = []string{"allow", "deny", "allowSegments", "denySegments"}
+
+// AddThrottleListEntry adds an identifier, or segment name, to one of the ThrottleLists of an existing throttle.
+// Adding an identifier that is already in the list does nothing.
+func (s *DynamoStore) AddThrottleListEntry(ctx context.Context, throttle, list, id string) error {
+	if err := validateThrottleList(list); err != nil {
+		return err
+	}
+	return s.addThrottleListValue(ctx, throttle, list, &types.AttributeValueMemberS{Value: id}, func(t models.ThrottleConfig) int {
+		return indexOf(throttleList(t, list), id)
+	})
+}
+
+// RemoveThrottleListEntry removes an identifier, or segment name, from one of the ThrottleLists of an existing throttle.
+func (s *DynamoStore) RemoveThrottleListEntry(ctx context.Context, throttle, list, id string) error {
+	if err := validateThrottleList(list); err != nil {
+		return err
+	}
+	return s.removeThrottleListValue(ctx, throttle, list, func(t models.ThrottleConfig) int {
+		return indexOf(throttleList(t, list), id)
+	})
+}
+
+// AddThrottleWhitelist adds a hash result to the whitelist of an existing throttle.
+// Adding a hash result that is already in the whitelist does nothing.
+func (s *DynamoStore) AddThrottleWhitelist(ctx context.Context, throttle string, hash uint) error {
+	return s.addThrottleListValue(ctx, throttle, "whitelist", hashValue(hash), func(t models.ThrottleConfig) int {
+		return indexOfHash(t.Whitelist, hash)
+	})
+}
+
+// RemoveThrottleWhitelist removes a hash result from the whitelist of an existing throttle.
+func (s *DynamoStore) RemoveThrottleWhitelist(ctx context.Context, throttle string, hash uint) error {
+	return s.removeThrottleListValue(ctx, throttle, "whitelist", func(t models.ThrottleConfig) int {
+		return indexOfHash(t.Whitelist, hash)
+	})
+}
+
+// addThrottleListValue appends a value to a list of an existing throttle, unless index finds it in the list already.
+func (s *DynamoStore) addThrottleListValue(ctx context.Context, throttle, list string, v types.AttributeValue, index func(models.ThrottleConfig) int) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		t, l, err := throttleListAttribute(item, throttle, list)
+		if err != nil {
+			return err
+		}
+		if index(t) >= 0 {
+			return nil
+		}
+		l.Value = append(l.Value, v)
+		return nil
+	})
+}
+
+// removeThrottleListValue removes a value from a list of an existing throttle, at the position found by index.
+func (s *DynamoStore) removeThrottleListValue(ctx context.Context, throttle, list string, index func(models.ThrottleConfig) int) error {
+	return s.update(ctx, func(item map[string]types.AttributeValue) error {
+		t, l, err := throttleListAttribute(item, throttle, list)
+		if err != nil {
+			return err
+		}
+		i := index(t)
+		if i < 0 || i >= len(l.Value) {
+			return ErrListEntryNotFound
+		}
+		l.Value = append(l.Value[:i], l.Value[i+1:]...)
+		return nil
+	})
+}
+
+// throttleListAttribute returns an existing throttle in an item, and the named list within it, which is created if it does not exist.
+func throttleListAttribute(item map[string]types.AttributeValue, throttle, list string) (models.ThrottleConfig, *types.AttributeValueMemberL, error) {
+	m, ok := throttleAttribute(item, throttle)
+	if !ok {
+		return models.ThrottleConfig{}, nil, ErrThrottleNotFound
+	}
+	var t models.ThrottleConfig
+	if err := unmarshalMap(m.Value, &t); err != nil {
+		return models.ThrottleConfig{}, nil, err
+	}
+	l, ok := m.Value[list].(*types.AttributeValueMemberL)
+	if !ok {
+		l = &types.AttributeValueMemberL{}
+		m.Value[list] = l
+	}
+	return t, l, nil
+}
+
+func validateThrottleList(list string) error {
+	if indexOf(ThrottleLists, list) < 0 {
+		return fmt.Errorf("unknown list %q, expected one of %s", list, strings.Join(ThrottleLists, ", "))
+	}
+	return nil
+}
+
+func throttleList(t models.ThrottleConfig, list string) []string {
+	switch list {
+	case "deny":
+		return t.Deny
+	case "allowSegments":
+		return t.AllowSegments
+	case "denySegments":
+		return t.DenySegments
+	}
+	return t.Allow
+}
+
+func hashValue(hash uint) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatUint(uint64(hash), 10)}
+}
+
+func indexOfHash(values []uint, v uint) int {
+	for i, h := range values {
+		if h == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func indexOf(values []string, v string) int {
+	for i, s := range values {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -16,7 +16,8 @@ import (
 
 const region = "eu-west-1"
 
-func CreateLocalTable(t *testing.T) (name string, testClient *dynamodb.Client, delete func()) {
+// LocalClient returns a client of DynamoDB Local, at DYNAMODB_ENDPOINT or localhost:8000.
+func LocalClient() *dynamodb.Client {
 	o := dynamodb.Options{
 		Credentials: credentials.NewStaticCredentialsProvider("fake", "accessKeyId", "secretKeyId"),
 		Region:      region,
@@ -25,8 +26,17 @@ func CreateLocalTable(t *testing.T) (name string, testClient *dynamodb.Client, d
 	if endpoint == "" {
 		endpoint = "http://localhost:8000"
 	}
-	testClient = dynamodb.New(o, dynamodb.WithEndpointResolver(dynamodb.EndpointResolverFromURL(endpoint)))
-	name = fmt.Sprintf("test-%s-%s", time.Now().Format("20060102-1504"), uuid.New())
+	return dynamodb.New(o, dynamodb.WithEndpointResolver(dynamodb.EndpointResolverFromURL(endpoint)))
+}
+
+// TableName returns a unique name for a test table.
+func TableName() string {
+	return fmt.Sprintf("test-%s-%s", time.Now().Format("20060102-1504"), uuid.New())
+}
+
+func CreateLocalTable(t *testing.T) (name string, testClient *dynamodb.Client, delete func()) {
+	testClient = LocalClient()
+	name = TableName()
 	_, err := testClient.CreateTable(context.Background(), &dynamodb.CreateTableInput{
 		AttributeDefinitions: []types.AttributeDefinition{
 			{
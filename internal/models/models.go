@@ -1,12 +1,222 @@
 package models
 
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
 type ThrottleConfig struct {
 	// Whitelist is a list of hash results that will always be allowed through the throttle.
 	Whitelist []uint `json:"whitelist,omitempty"`
+	// Allow is a list of identifiers that are always allowed through the throttle.
+	Allow []string `json:"allow,omitempty"`
+	// Deny is a list of identifiers that are never allowed through the throttle, even if they are in Allow.
+	Deny []string `json:"deny,omitempty"`
+	// AllowSegments are the names of segments whose members are always allowed through the throttle.
+	AllowSegments []string `json:"allowSegments,omitempty"`
+	// DenySegments are the names of segments whose members are never allowed through the throttle.
+	DenySegments []string `json:"denySegments,omitempty"`
 	// Probability of a hash result making it through the throttle.
 	Probability float64 `json:"probability,omitempty"`
 	// When true will force the rejection for all the requests going through the throttle
 	ForceRejectAll bool `json:"forceRejectAll,omitempty"`
+	// EnableAt is an optional time before which all requests are rejected.
+	EnableAt *time.Time `json:"enableAt,omitempty"`
+	// DisableAt is an optional time from which all requests are rejected.
+	DisableAt *time.Time `json:"disableAt,omitempty"`
+	// Ramp optionally replaces Probability with one that changes over time.
+	Ramp *Ramp `json:"ramp,omitempty"`
+	// Prerequisites must all be met for any requests to be allowed.
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty"`
+	// Seed is hashed with each input instead of the throttle's key when set.
+	// Throttles that share a seed bucket inputs identically.
+	Seed string `json:"seed,omitempty"`
+	// HashAlgorithm is the algorithm used to bucket inputs, one of HashAlgorithms. Defaults to fnv32a.
+	HashAlgorithm string `json:"hashAlgorithm,omitempty"`
+	// Buckets is the number of buckets that inputs are hashed into. Defaults to DefaultBuckets.
+	Buckets uint `json:"buckets,omitempty"`
+	// Metadata describes the throttle, it does not affect evaluation.
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// DefaultBuckets is the bucket resolution of throttles without Buckets, giving a precision of 0.01%.
+const DefaultBuckets = 100_00
+
+// MaxBuckets is the largest bucket resolution, the range of a 32 bit hash.
+const MaxBuckets = 1 << 32
+
+// BucketCount returns Buckets, or DefaultBuckets if it is not set.
+func (t ThrottleConfig) BucketCount() uint {
+	if t.Buckets == 0 {
+		return DefaultBuckets
+	}
+	return t.Buckets
+}
+
+// BucketThreshold returns the highest bucket that is allowed at the given probability.
+func (t ThrottleConfig) BucketThreshold(probability float64) uint {
+	return uint(math.Floor(probability * (float64(t.BucketCount()) / 100)))
+}
+
+// Validate returns an error if the throttle cannot be evaluated.
+func (t ThrottleConfig) Validate() error {
+	if !ValidHashAlgorithm(t.HashAlgorithm) {
+		return fmt.Errorf("unknown hash algorithm: %s", t.HashAlgorithm)
+	}
+	if uint64(t.Buckets) > MaxBuckets {
+		return fmt.Errorf("buckets must be at most %d", uint64(MaxBuckets))
+	}
+	return nil
+}
+
+// HashAlgorithms are the supported values of ThrottleConfig.HashAlgorithm.
+var HashAlgorithms = []string{"fnv32a", "murmur3", "sha1"}
+
+// ValidHashAlgorithm returns whether algorithm is empty or one of HashAlgorithms.
+func ValidHashAlgorithm(algorithm string) bool {
+	if algorithm == "" {
+		return true
+	}
+	for _, a := range HashAlgorithms {
+		if a == algorithm {
+			return true
+		}
+	}
+	return false
+}
+
+// HashSeed returns the value that is hashed with each input for the throttle with the given key.
+func (t ThrottleConfig) HashSeed(key string) string {
+	if t.Seed != "" {
+		return t.Seed
+	}
+	return key
+}
+
+// Active returns whether now is within the throttle's schedule.
+func (t ThrottleConfig) Active(now time.Time) bool {
+	return active(t.EnableAt, t.DisableAt, now)
+}
+
+// EffectiveProbability returns the probability of the throttle at the given time.
+func (t ThrottleConfig) EffectiveProbability(now time.Time) float64 {
+	if t.Ramp == nil {
+		return t.Probability
+	}
+	return t.Ramp.Probability(now, t.Probability)
+}
+
+// RampStep is a probability that a Ramp reaches at a given time.
+type RampStep struct {
+	At          time.Time `json:"at"`
+	Probability float64   `json:"probability"`
+}
+
+// Ramp is a schedule of probabilities for a throttle.
+type Ramp struct {
+	// Steps do not need to be in order.
+	Steps []RampStep `json:"steps"`
+	// Linear interpolates the probability between steps, rather than changing it at each step.
+	Linear bool `json:"linear,omitempty"`
+}
+
+// Probability returns the probability of the ramp at the given time.
+// Before the first step, initial is returned.
+func (r Ramp) Probability(now time.Time, initial float64) float64 {
+	var prev, next *RampStep
+	for i := range r.Steps {
+		s := &r.Steps[i]
+		if !now.Before(s.At) {
+			if prev == nil || s.At.After(prev.At) {
+				prev = s
+			}
+			continue
+		}
+		if next == nil || s.At.Before(next.At) {
+			next = s
+		}
+	}
+	if prev == nil {
+		return initial
+	}
+	if !r.Linear || next == nil {
+		return prev.Probability
+	}
+	progress := float64(now.Sub(prev.At)) / float64(next.At.Sub(prev.At))
+	return prev.Probability + (next.Probability-prev.Probability)*progress
+}
+
+// FeatureConfig holds settings for an entry in Features.
+type FeatureConfig struct {
+	// EnableAt is an optional time before which the feature is off.
+	EnableAt *time.Time `json:"enableAt,omitempty"`
+	// DisableAt is an optional time from which the feature is off.
+	DisableAt *time.Time `json:"disableAt,omitempty"`
+	// Prerequisites must all be met for the feature to be on.
+	Prerequisites []Prerequisite `json:"prerequisites,omitempty"`
+	// Metadata describes the feature, it does not affect evaluation.
+	Metadata *Metadata `json:"metadata,omitempty"`
+}
+
+// Metadata describes a feature or throttle.
+type Metadata struct {
+	Description string `json:"description,omitempty"`
+	// Owner is the team responsible for the flag.
+	Owner     string     `json:"owner,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// ExpiresAt is when the flag is intended to be removed.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Permanent flags are not intended to be removed, flags are temporary by default.
+	Permanent bool `json:"permanent,omitempty"`
+}
+
+// Expired returns whether the flag is temporary and past its intended expiry.
+func (m Metadata) Expired(now time.Time) bool {
+	return !m.Permanent && m.ExpiresAt != nil && !now.Before(*m.ExpiresAt)
+}
+
+// HasTag returns whether the flag has the given tag.
+func (m Metadata) HasTag(tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Prerequisite is a feature that must have a given value.
+type Prerequisite struct {
+	// Flag is the key of the feature in Features.
+	Flag string `json:"flag"`
+	// Value is the value that the feature must have. Defaults to true.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// RequiredValue returns Value, or true if it is not set.
+func (p Prerequisite) RequiredValue() interface{} {
+	if p.Value == nil {
+		return true
+	}
+	return p.Value
+}
+
+// Active returns whether now is within the feature's schedule.
+func (c FeatureConfig) Active(now time.Time) bool {
+	return active(c.EnableAt, c.DisableAt, now)
+}
+
+func active(enableAt, disableAt *time.Time, now time.Time) bool {
+	if enableAt != nil && now.Before(*enableAt) {
+		return false
+	}
+	if disableAt != nil && !now.Before(*disableAt) {
+		return false
+	}
+	return true
 }
 
 type Features map[string]interface{}
@@ -19,4 +229,140 @@ func (f Features) Bool(s string) bool {
 type StoreDocument struct {
 	Features  Features                  `json:"features"`
 	Throttles map[string]ThrottleConfig `json:"throttles"`
+	// FeatureConfigs holds optional settings for entries in Features, keyed by feature name.
+	FeatureConfigs map[string]FeatureConfig `json:"featureConfigs,omitempty"`
+	// KillSwitch makes every feature and throttle fall back to its safe default.
+	KillSwitch bool `json:"killSwitch,omitempty"`
+	// Freeze, when set, makes the CLI refuse writes to the document.
+	Freeze *Freeze `json:"freeze,omitempty"`
+	// Version is incremented by every write to the document.
+	Version int64 `json:"version,omitempty"`
+}
+
+// Freeze records why and when a document was frozen.
+type Freeze struct {
+	Reason string    `json:"reason"`
+	At     time.Time `json:"at"`
+}
+
+// PrerequisiteCycle returns a chain of features that depend on each other, or nil if there is none.
+func (d StoreDocument) PrerequisiteCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var path []string
+	var visit func(f string) []string
+	visit = func(f string) []string {
+		switch state[f] {
+		case visiting:
+			for i, p := range path {
+				if p == f {
+					return append(append([]string{}, path[i:]...), f)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[f] = visiting
+		path = append(path, f)
+		for _, p := range d.FeatureConfigs[f].Prerequisites {
+			if c := visit(p.Flag); c != nil {
+				return c
+			}
+		}
+		path = path[:len(path)-1]
+		state[f] = visited
+		return nil
+	}
+	keys := make([]string, 0, len(d.FeatureConfigs))
+	for k := range d.FeatureConfigs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if c := visit(k); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// Dependents returns the features and throttles that have flag as a prerequisite.
+func (d StoreDocument) Dependents(flag string) (features, throttles []string) {
+	for k, c := range d.FeatureConfigs {
+		if hasPrerequisite(c.Prerequisites, flag) {
+			features = append(features, k)
+		}
+	}
+	for k, t := range d.Throttles {
+		if hasPrerequisite(t.Prerequisites, flag) {
+			throttles = append(throttles, k)
+		}
+	}
+	sort.Strings(features)
+	sort.Strings(throttles)
+	return features, throttles
+}
+
+func hasPrerequisite(ps []Prerequisite, flag string) bool {
+	for _, p := range ps {
+		if p.Flag == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeDocuments combines docs, where later docs take precedence for each feature and throttle.
+// The kill switch is on if it is on in any of the docs.
+func MergeDocuments(docs ...StoreDocument) StoreDocument {
+	m := StoreDocument{
+		Features:       make(Features),
+		Throttles:      make(map[string]ThrottleConfig),
+		FeatureConfigs: make(map[string]FeatureConfig),
+	}
+	for _, d := range docs {
+		for k, v := range d.FeatureConfigs {
+			m.FeatureConfigs[k] = v
+		}
+		for k, v := range d.Features {
+			m.Features[k] = v
+			if _, ok := d.FeatureConfigs[k]; !ok {
+				delete(m.FeatureConfigs, k)
+			}
+		}
+		for k, v := range d.Throttles {
+			m.Throttles[k] = v
+		}
+		m.KillSwitch = m.KillSwitch || d.KillSwitch
+		if d.Freeze != nil {
+			m.Freeze = d.Freeze
+		}
+	}
+	return m
+}
+
+// SegmentManifest describes a segment, a large list of identifiers that is stored apart from the features record.
+// The sorted members are split into chunks that are each stored as a separate item.
+type SegmentManifest struct {
+	// Generation identifies the chunks of the current version of the segment.
+	Generation string `json:"generation"`
+	// Count is the number of members in the segment.
+	Count int `json:"count"`
+	// Chunks holds the first member of each chunk, in order.
+	Chunks []string `json:"chunks"`
+	// Version is incremented by every write of the segment, each of which has an audit entry.
+	Version int64 `json:"version,omitempty"`
+}
+
+// Chunk returns the index of the only chunk that could contain id, or -1 if there is none.
+func (m SegmentManifest) Chunk(id string) int {
+	i := sort.SearchStrings(m.Chunks, id)
+	if i < len(m.Chunks) && m.Chunks[i] == id {
+		return i
+	}
+	return i - 1
 }
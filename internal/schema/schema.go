@@ -0,0 +1,252 @@
+// Package schema validates feature documents against the versioned JSON Schema of StoreDocument.
+package schema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// Version is the version of the schema that documents are validated against.
+const Version = 2
+
+// JSON is the JSON Schema of the current Version.
+//
+//go:embed storedocument.v2.json
+var JSON []byte
+
+var root = func() map[string]interface{} {
+	var s map[string]interface{}
+	if err := json.Unmarshal(JSON, &s); err != nil {
+		panic(fmt.Sprintf("schema: invalid embedded schema: %v", err))
+	}
+	return s
+}()
+
+// Error is a single problem with a document.
+type Error struct {
+	// Path is the location of the problem, e.g. "throttles.search.probability".
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// InvalidDocumentError is returned when loading a document that does not match the schema.
+type InvalidDocumentError struct {
+	Record string
+	Errors []Error
+}
+
+func (e *InvalidDocumentError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("invalid document %s: %s", e.Record, strings.Join(msgs, "; "))
+}
+
+// Validate returns the problems with a document decoded from JSON or a DynamoDB item, ordered by path.
+// Numbers must be float64, as they are when decoded into an interface{}.
+// As well as the schema, documents must not have cycles of prerequisites.
+func Validate(doc map[string]interface{}) []Error {
+	var errs []Error
+	validate(root, "", doc, &errs)
+	if len(errs) == 0 {
+		errs = append(errs, semantic(doc)...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return errs
+}
+
+func semantic(doc map[string]interface{}) []Error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+	var d models.StoreDocument
+	if err := json.Unmarshal(b, &d); err != nil {
+		return []Error{{Message: err.Error()}}
+	}
+	if c := d.PrerequisiteCycle(); c != nil {
+		return []Error{{Message: "prerequisite cycle: " + strings.Join(c, " -> ")}}
+	}
+	return nil
+}
+
+// validate checks v against the subset of JSON Schema used by the embedded schema.
+func validate(s map[string]interface{}, path string, v interface{}, errs *[]Error) {
+	if ref, ok := s["$ref"].(string); ok {
+		s = resolve(ref)
+	}
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+			}
+		}
+		if !found {
+			fail("must be one of %s", describeEnum(enum))
+			return
+		}
+	}
+	if t, ok := s["type"].(string); ok && !hasType(t, v) {
+		fail("must be %s, got %s", article(t), describe(v))
+		return
+	}
+	if ts, ok := s["type"].([]interface{}); ok {
+		found := false
+		names := make([]string, len(ts))
+		for i, t := range ts {
+			names[i] = article(t.(string))
+			found = found || hasType(t.(string), v)
+		}
+		if !found {
+			last := len(names) - 1
+			fail("must be %s or %s, got %s", strings.Join(names[:last], ", "), names[last], describe(v))
+			return
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		required, _ := s["required"].([]interface{})
+		for _, r := range required {
+			if _, ok := v[r.(string)]; !ok {
+				fail("missing required property %q", r)
+			}
+		}
+		for _, k := range sortedKeys(v) {
+			p := join(path, k)
+			if ps, ok := props[k].(map[string]interface{}); ok {
+				validate(ps, p, v[k], errs)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					*errs = append(*errs, Error{Path: p, Message: "unknown property"})
+				}
+			case map[string]interface{}:
+				validate(ap, p, v[k], errs)
+			}
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(items, fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && v < min {
+			fail("must be at least %v", min)
+		}
+		if max, ok := s["maximum"].(float64); ok && v > max {
+			fail("must be at most %v", max)
+		}
+	case string:
+		if s["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, v); err != nil {
+				fail("must be an RFC3339 time, got %q", v)
+			}
+		}
+	}
+}
+
+func resolve(ref string) map[string]interface{} {
+	s := root
+	for _, p := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		s = s[p].(map[string]interface{})
+	}
+	return s
+}
+
+func hasType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+func describe(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case bool:
+		return fmt.Sprintf("boolean %v", v)
+	case float64:
+		return fmt.Sprintf("number %v", v)
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describeEnum(enum []interface{}) string {
+	s := make([]string, len(enum))
+	for i, e := range enum {
+		b, _ := json.Marshal(e)
+		s[i] = string(b)
+	}
+	return strings.Join(s, ", ")
+}
+
+func article(t string) string {
+	if t == "object" || t == "array" || t == "integer" {
+		return "an " + t
+	}
+	return "a " + t
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		expected []Error
+	}{
+		{
+			name: "empty document",
+			doc:  `{}`,
+		},
+		{
+			name: "valid document",
+			doc: `{
+				"_pk": "features",
+				"features": {"newCheckout": true, "parent": false},
+				"featureConfigs": {
+					"newCheckout": {
+						"enableAt": "2022-11-25T00:00:00Z",
+						"prerequisites": [{"flag": "parent"}, {"flag": "other", "value": false}],
+						"metadata": {"owner": "payments", "tags": ["payments"], "permanent": true}
+					}
+				},
+				"throttles": {
+					"search": {
+						"probability": 2.5,
+						"whitelist": [10, 3321],
+						"allow": ["user-1"],
+						"denySegments": ["blocked"],
+						"ramp": {"steps": [{"at": "2022-11-25T00:00:00Z", "probability": 5}], "linear": true},
+						"hashAlgorithm": "murmur3",
+						"buckets": 1000000
+					}
+				},
+				"killSwitch": false,
+				"freeze": {"reason": "incident", "at": "2022-11-25T00:00:00Z"}
+			}`,
+		},
+		{
+			name: "string probability",
+			doc:  `{"throttles": {"search": {"probability": "5"}}}`,
+			expected: []Error{
+				{Path: "throttles.search.probability", Message: `must be a number, got string "5"`},
+			},
+		},
+		{
+			name: "typed features",
+			doc:  `{"features": {"banner": "Welcome", "maxItems": 25, "limits": {"daily": 10}, "regions": ["eu"]}}`,
+		},
+		{
+			name: "null feature",
+			doc:  `{"features": {"newCheckout": null}}`,
+			expected: []Error{
+				{Path: "features.newCheckout", Message: "must be a boolean, a string, a number, an object or an array, got null"},
+			},
+		},
+		{
+			name: "several problems",
+			doc: `{
+				"features": {"a": true},
+				"featureConfigs": {"a": {"enableAt": "tomorrow", "prerequisites": [{"value": true}]}},
+				"throttles": {"search": {"probabilty": 5, "probability": 101, "whitelist": [1.5], "hashAlgorithm": "md5"}},
+				"throtles": {}
+			}`,
+			expected: []Error{
+				{Path: "featureConfigs.a.enableAt", Message: `must be an RFC3339 time, got "tomorrow"`},
+				{Path: "featureConfigs.a.prerequisites[0]", Message: `missing required property "flag"`},
+				{Path: "throtles", Message: "unknown property"},
+				{Path: "throttles.search.hashAlgorithm", Message: `must be one of "", "fnv32a", "murmur3", "sha1"`},
+				{Path: "throttles.search.probability", Message: "must be at most 100"},
+				{Path: "throttles.search.probabilty", Message: "unknown property"},
+				{Path: "throttles.search.whitelist[0]", Message: "must be an integer, got number 1.5"},
+			},
+		},
+		{
+			name: "prerequisite cycle",
+			doc: `{
+				"features": {"a": true, "b": true},
+				"featureConfigs": {"a": {"prerequisites": [{"flag": "b"}]}, "b": {"prerequisites": [{"flag": "a"}]}}
+			}`,
+			expected: []Error{
+				{Message: "prerequisite cycle: a -> b -> a"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var doc map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, Validate(doc)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
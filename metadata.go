@@ -0,0 +1,82 @@
+package flagship
+
+import (
+	"sort"
+
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// Metadata describes a feature or throttle: its description, owner, tags, creation date and intended expiry.
+// It is stored under "metadata" in the feature's config, or the throttle, and does not affect evaluation.
+type Metadata = models.Metadata
+
+// FlagType is the kind of a flag.
+type FlagType string
+
+const (
+	FeatureFlag  FlagType = "feature"
+	ThrottleFlag FlagType = "throttle"
+)
+
+// Flag describes a feature or throttle in a snapshot.
+type Flag struct {
+	Key  string
+	Type FlagType
+	Metadata
+}
+
+// Flags returns every feature and throttle in the snapshot, ordered by key with features first.
+// Flags without metadata have an empty Metadata.
+//
+//	snap, err := s.(flagship.Snapshotter).Snapshot(ctx)
+//	for _, f := range snap.Flags() {
+//		if f.Expired(time.Now()) {
+//			log.Printf("%s owned by %s has expired", f.Key, f.Owner)
+//		}
+//	}
+func (s *Snapshot) Flags() []Flag {
+	var flags []Flag
+	for k := range s.features {
+		flags = append(flags, s.featureFlag(k))
+	}
+	for k := range s.throttles {
+		flags = append(flags, s.throttleFlag(k))
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		if flags[i].Type != flags[j].Type {
+			return flags[i].Type == FeatureFlag
+		}
+		return flags[i].Key < flags[j].Key
+	})
+	return flags
+}
+
+// Flag returns the feature, or if there is none the throttle, with the given key.
+func (s *Snapshot) Flag(key string) (Flag, bool) {
+	if ns, k, ok := s.namespace(key); ok {
+		return ns.Flag(k)
+	}
+	if _, ok := s.features[key]; ok {
+		return s.featureFlag(key), true
+	}
+	if _, ok := s.throttles[key]; ok {
+		return s.throttleFlag(key), true
+	}
+	return Flag{}, false
+}
+
+func (s *Snapshot) featureFlag(key string) Flag {
+	f := Flag{Key: key, Type: FeatureFlag}
+	if m := s.featureConfigs[key].Metadata; m != nil {
+		f.Metadata = *m
+	}
+	return f
+}
+
+func (s *Snapshot) throttleFlag(key string) Flag {
+	f := Flag{Key: key, Type: ThrottleFlag}
+	if m := s.throttles[key].Metadata; m != nil {
+		f.Metadata = *m
+	}
+	return f
+}
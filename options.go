@@ -50,6 +50,30 @@ func WithRecordName(recordName string) Option {
 	}
 }
 
+// WithRecordNames allows the feature document to be merged from several AWS DynamoDB partition keys,
+// loaded together. Where records define the same feature or throttle, later records take precedence.
+// A single record can be queried by prefixing keys with its name and a "/".
+//
+//	s, err := flagship.New(context.Background(), flagship.WithRecordNames("global", "payments"))
+//	s.Bool(context.Background(), "newcheckout") // merged view
+//	s.Bool(context.Background(), "payments/newcheckout") // payments record only
+func WithRecordNames(recordNames ...string) Option {
+	return func(fsc *featureStoreConfig) {
+		fsc.RecordNames = recordNames
+	}
+}
+
+// WithEnvironment allows the selection of an environment, such as "staging", within the AWS DynamoDB table.
+// Each record is read from the partition key "<recordName>#<environment>".
+// The default value is "", which reads each record from the partition key "<recordName>".
+//
+//	s, err := flagship.New(context.Background(), flagship.WithEnvironment("staging"))
+func WithEnvironment(env string) Option {
+	return func(fsc *featureStoreConfig) {
+		fsc.Environment = env
+	}
+}
+
 // WithTTL allows modification of the cache expiry for features.
 // The default value is 30 seconds.
 //
@@ -79,3 +103,34 @@ func WithLogger(logger *log.Logger) Option {
 		fsc.Logger = logger
 	}
 }
+
+// WithSafeDefaults registers the values that Bool and ThrottleAllow return while the kill switch
+// of the document is on. Keys that are not registered return false.
+//
+//	s, err := flagship.New(context.Background(), flagship.WithSafeDefaults(map[string]bool{"search": true}))
+func WithSafeDefaults(defaults map[string]bool) Option {
+	return func(fsc *featureStoreConfig) {
+		fsc.SafeDefaults = defaults
+	}
+}
+
+// WithSegmentCacheSize sets the maximum number of bytes of segment members held in memory.
+// The least recently used parts of segments are evicted first. The default value is 32MiB.
+//
+//	s, err := flagship.New(context.Background(), flagship.WithSegmentCacheSize(8<<20))
+func WithSegmentCacheSize(bytes int) Option {
+	return func(fsc *featureStoreConfig) {
+		fsc.SegmentCacheSize = bytes
+	}
+}
+
+// WithStrictValidation validates each document against the schema when it is loaded.
+// If a document is invalid then the last valid snapshot continues to be used, and the problems are logged.
+// If there is no valid snapshot then New returns an *InvalidDocumentError.
+//
+//	s, err := flagship.New(context.Background(), flagship.WithStrictValidation())
+func WithStrictValidation() Option {
+	return func(fsc *featureStoreConfig) {
+		fsc.StrictValidation = true
+	}
+}
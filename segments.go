@@ -0,0 +1,175 @@
+package flagship
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// manifestFailureTTL is how long a snapshot remembers that a segment manifest failed to load,
+// so that a missing segment does not cost a read on every evaluation.
+const manifestFailureTTL = 5 * time.Second
+
+// segmentLoader loads segments, which are stored apart from the features record.
+type segmentLoader interface {
+	LoadSegmentManifest(ctx context.Context, name string) (models.SegmentManifest, error)
+	LoadSegmentChunk(ctx context.Context, name, generation string, chunk int) ([]string, error)
+}
+
+type chunkKey struct {
+	name, generation string
+	chunk            int
+}
+
+type chunkEntry struct {
+	key     chunkKey
+	members []string
+	size    int
+}
+
+// segmentCache holds the most recently used segment chunks, up to maxBytes, and is shared between snapshots.
+// Chunks are immutable, a new generation of a segment is written to new chunks.
+type segmentCache struct {
+	loader   segmentLoader
+	maxBytes int
+	mu       sync.Mutex
+	size     int
+	lru      *list.List
+	chunks   map[chunkKey]*list.Element
+}
+
+func newSegmentCache(loader segmentLoader, maxBytes int) *segmentCache {
+	return &segmentCache{
+		loader:   loader,
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		chunks:   make(map[chunkKey]*list.Element),
+	}
+}
+
+func (c *segmentCache) chunk(ctx context.Context, key chunkKey) ([]string, error) {
+	c.mu.Lock()
+	if e, ok := c.chunks[key]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*chunkEntry).members, nil
+	}
+	c.mu.Unlock()
+	members, err := c.loader.LoadSegmentChunk(ctx, key.name, key.generation, key.chunk)
+	if err != nil {
+		return nil, err
+	}
+	entry := &chunkEntry{key: key, members: members}
+	for _, m := range members {
+		// Account for the string header as well as its contents.
+		entry.size += len(m) + 16
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.chunks[key]; ok {
+		return e.Value.(*chunkEntry).members, nil
+	}
+	c.chunks[key] = c.lru.PushFront(entry)
+	c.size += entry.size
+	for c.size > c.maxBytes && c.lru.Len() > 1 {
+		e := c.lru.Back()
+		old := e.Value.(*chunkEntry)
+		c.lru.Remove(e)
+		delete(c.chunks, old.key)
+		c.size -= old.size
+	}
+	return members, nil
+}
+
+// segments resolves segment membership for a snapshot, loading each manifest at most once.
+func (c *segmentCache) segments(now func() time.Time) *segments {
+	return &segments{
+		cache:     c,
+		now:       now,
+		manifests: make(map[string]models.SegmentManifest),
+		failures:  make(map[string]manifestFailure),
+	}
+}
+
+type manifestFailure struct {
+	err   error
+	until time.Time
+}
+
+type segments struct {
+	cache     *segmentCache
+	now       func() time.Time
+	mu        sync.Mutex
+	manifests map[string]models.SegmentManifest
+	failures  map[string]manifestFailure
+}
+
+func (s *segments) manifest(ctx context.Context, name string) (models.SegmentManifest, error) {
+	s.mu.Lock()
+	m, ok := s.manifests[name]
+	f, failed := s.failures[name]
+	s.mu.Unlock()
+	if ok {
+		return m, nil
+	}
+	if failed && s.now().Before(f.until) {
+		return models.SegmentManifest{}, f.err
+	}
+	return s.load(ctx, name)
+}
+
+// load loads the manifest of a segment, replacing any the snapshot already holds.
+func (s *segments) load(ctx context.Context, name string) (models.SegmentManifest, error) {
+	m, err := s.cache.loader.LoadSegmentManifest(ctx, name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		delete(s.manifests, name)
+		s.failures[name] = manifestFailure{err: err, until: s.now().Add(manifestFailureTTL)}
+		return models.SegmentManifest{}, err
+	}
+	delete(s.failures, name)
+	s.manifests[name] = m
+	return m, nil
+}
+
+// contains returns whether id is a member of the named segment.
+// Only the chunk that could contain id is loaded.
+// The chunks of a generation are deleted once a segment is replaced, so if a chunk is missing the manifest is reloaded.
+func (s *segments) contains(ctx context.Context, name, id string) (bool, error) {
+	m, err := s.manifest(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	members, err := s.chunk(ctx, name, m, id)
+	if errors.Is(err, dynamostore.ErrSegmentNotFound) {
+		latest, lerr := s.load(ctx, name)
+		if lerr != nil {
+			return false, lerr
+		}
+		if latest.Generation == m.Generation {
+			return false, err
+		}
+		members, err = s.chunk(ctx, name, latest, id)
+	}
+	if err != nil {
+		return false, err
+	}
+	j := sort.SearchStrings(members, id)
+	return j < len(members) && members[j] == id, nil
+}
+
+// chunk returns the members of the chunk of a generation that could contain id.
+func (s *segments) chunk(ctx context.Context, name string, m models.SegmentManifest, id string) ([]string, error) {
+	i := m.Chunk(id)
+	if i < 0 {
+		return nil, nil
+	}
+	return s.cache.chunk(ctx, chunkKey{name: name, generation: m.Generation, chunk: i})
+}
@@ -0,0 +1,129 @@
+package flagship
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+	"github.com/joerdav/flagship/internal/models"
+)
+
+type fakeSegmentLoader struct {
+	chunks        map[string][][]string
+	generation    string
+	loads         int
+	manifestLoads int
+}
+
+func (l *fakeSegmentLoader) LoadSegmentManifest(ctx context.Context, name string) (models.SegmentManifest, error) {
+	l.manifestLoads++
+	chunks, ok := l.chunks[name]
+	if !ok {
+		return models.SegmentManifest{}, errors.New("segment not found")
+	}
+	m := models.SegmentManifest{Generation: l.generation}
+	for _, c := range chunks {
+		m.Chunks = append(m.Chunks, c[0])
+		m.Count += len(c)
+	}
+	return m, nil
+}
+
+func (l *fakeSegmentLoader) LoadSegmentChunk(ctx context.Context, name, generation string, chunk int) ([]string, error) {
+	l.loads++
+	if generation != l.generation {
+		return nil, fmt.Errorf("%w: chunk %d of %s", dynamostore.ErrSegmentNotFound, chunk, name)
+	}
+	return l.chunks[name][chunk], nil
+}
+
+func TestSegmentsContains(t *testing.T) {
+	loader := &fakeSegmentLoader{chunks: map[string][][]string{
+		"tenants": {{"b", "c", "d"}, {"f", "g"}, {"x", "y", "z"}},
+	}}
+	s := newSegmentCache(loader, 1<<20).segments(time.Now)
+	for id, expected := range map[string]bool{
+		"a": false, "b": true, "c": true, "e": false, "f": true, "g": true, "h": false, "z": true, "zz": false,
+	} {
+		ok, err := s.contains(context.Background(), "tenants", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != expected {
+			t.Errorf("contains(%q) = %v, expected %v", id, ok, expected)
+		}
+	}
+	if loader.loads != 3 {
+		t.Errorf("expected each chunk to be loaded once, loaded %d times", loader.loads)
+	}
+	if _, err := s.contains(context.Background(), "missing", "a"); err == nil {
+		t.Error("expected an error for a missing segment")
+	}
+}
+
+func TestSegmentCacheEviction(t *testing.T) {
+	var chunks [][]string
+	for i := 0; i < 10; i++ {
+		chunks = append(chunks, []string{fmt.Sprintf("%02d", i)})
+	}
+	loader := &fakeSegmentLoader{chunks: map[string][][]string{"tenants": chunks}}
+	// Each chunk is 18 bytes, so only two fit.
+	c := newSegmentCache(loader, 40)
+	s := c.segments(time.Now)
+	for i := 0; i < 10; i++ {
+		if ok, err := s.contains(context.Background(), "tenants", fmt.Sprintf("%02d", i)); err != nil || !ok {
+			t.Fatalf("contains(%02d) = %v, %v", i, ok, err)
+		}
+	}
+	if c.size > 40 || c.lru.Len() != 2 {
+		t.Errorf("expected cache to hold 2 chunks within 40 bytes, holds %d in %d bytes", c.lru.Len(), c.size)
+	}
+	loads := loader.loads
+	if _, err := s.contains(context.Background(), "tenants", "09"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.contains(context.Background(), "tenants", "00"); err != nil {
+		t.Fatal(err)
+	}
+	if loader.loads != loads+1 {
+		t.Errorf("expected only the evicted chunk to be reloaded, loaded %d times", loader.loads-loads)
+	}
+}
+
+func TestSegmentsReplaced(t *testing.T) {
+	loader := &fakeSegmentLoader{chunks: map[string][][]string{"tenants": {{"a"}, {"m"}}}, generation: "1"}
+	s := newSegmentCache(loader, 1<<20).segments(time.Now)
+	if ok, err := s.contains(context.Background(), "tenants", "a"); err != nil || !ok {
+		t.Fatalf("contains(a) = %v, %v", ok, err)
+	}
+	// Replace the segment, deleting the chunks of the generation the snapshot holds.
+	loader.chunks["tenants"] = [][]string{{"c"}, {"n"}}
+	loader.generation = "2"
+	if ok, err := s.contains(context.Background(), "tenants", "n"); err != nil || !ok {
+		t.Errorf("expected the manifest to be reloaded when its chunks are missing, contains(n) = %v, %v", ok, err)
+	}
+}
+
+func TestSegmentsManifestFailure(t *testing.T) {
+	loader := &fakeSegmentLoader{chunks: map[string][][]string{}}
+	now := time.Date(2022, 11, 22, 14, 0, 0, 0, time.UTC)
+	s := newSegmentCache(loader, 1<<20).segments(func() time.Time { return now })
+	for i := 0; i < 3; i++ {
+		if _, err := s.contains(context.Background(), "missing", "a"); err == nil {
+			t.Fatal("expected an error for a missing segment")
+		}
+	}
+	if loader.manifestLoads != 1 {
+		t.Errorf("expected the failure to be remembered, loaded the manifest %d times", loader.manifestLoads)
+	}
+	now = now.Add(manifestFailureTTL)
+	if _, err := s.contains(context.Background(), "missing", "a"); err == nil {
+		t.Fatal("expected an error for a missing segment")
+	}
+	if loader.manifestLoads != 2 {
+		t.Errorf("expected the manifest to be loaded again once the failure expires, loaded %d times", loader.manifestLoads)
+	}
+}
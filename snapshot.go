@@ -0,0 +1,293 @@
+package flagship
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/joerdav/flagship/internal/models"
+)
+
+// compile time check that Snapshot implements FeatureStore.
+var _ FeatureStore = &Snapshot{}
+
+type throttleConfigInt struct {
+	models.ThrottleConfig
+	// Threshold is an integer representation of Probability. Floor(Probability*BucketCount()/100)
+	Threshold uint
+	// allow and deny are the sets of identifiers in Allow and Deny.
+	allow, deny map[string]bool
+}
+
+// threshold returns Threshold, or the equivalent for the ramp at the given time if there is one.
+func (t *throttleConfigInt) threshold(now time.Time) uint {
+	if t.Ramp == nil {
+		return t.Threshold
+	}
+	return t.BucketThreshold(t.EffectiveProbability(now))
+}
+
+// Snapshot is a FeatureStore backed by a single loaded version of the feature document.
+// It never refreshes, so every evaluation made against it is consistent.
+type Snapshot struct {
+	features       models.Features
+	featureConfigs map[string]models.FeatureConfig
+	throttles      map[string]*throttleConfigInt
+	killSwitch     bool
+	version        int64
+	safeDefaults   map[string]bool
+	now            func() time.Time
+	logger         *log.Logger
+	// namespaces holds a snapshot of each record that was merged into this one.
+	namespaces map[string]*Snapshot
+	// segments resolves the members of segments referenced by throttles.
+	segments *segments
+}
+
+func newSnapshot(doc models.StoreDocument, now func() time.Time, logger *log.Logger, safeDefaults map[string]bool) *Snapshot {
+	s := &Snapshot{
+		features:       doc.Features,
+		featureConfigs: doc.FeatureConfigs,
+		throttles:      make(map[string]*throttleConfigInt),
+		killSwitch:     doc.KillSwitch,
+		version:        doc.Version,
+		safeDefaults:   safeDefaults,
+		now:            now,
+		logger:         logger,
+	}
+	for k, th := range doc.Throttles {
+		s.throttles[k] = &throttleConfigInt{
+			ThrottleConfig: th,
+			Threshold:      th.BucketThreshold(th.Probability),
+			allow:          set(th.Allow),
+			deny:           set(th.Deny),
+		}
+	}
+	return s
+}
+
+// newMergedSnapshot returns a snapshot of docs merged in order, where each doc can also be queried
+// by prefixing keys with its record name and a "/".
+func newMergedSnapshot(records []string, docs []models.StoreDocument, now func() time.Time, logger *log.Logger, safeDefaults map[string]bool, segments *segments) *Snapshot {
+	s := newSnapshot(models.MergeDocuments(docs...), now, logger, safeDefaults)
+	s.segments = segments
+	s.namespaces = make(map[string]*Snapshot)
+	for i, r := range records {
+		s.namespaces[r] = newSnapshot(docs[i], now, logger, safeDefaults)
+		s.namespaces[r].segments = segments
+	}
+	if len(docs) == 1 {
+		s.version = docs[0].Version
+	}
+	return s
+}
+
+// Version returns the version of the loaded document, which is incremented by every write.
+// When several records are merged it returns 0, use Versions instead.
+func (s *Snapshot) Version() int64 {
+	return s.version
+}
+
+// Versions returns the version of each record that was merged into the snapshot.
+func (s *Snapshot) Versions() map[string]int64 {
+	v := make(map[string]int64, len(s.namespaces))
+	for r, ns := range s.namespaces {
+		v[r] = ns.version
+	}
+	return v
+}
+
+// namespace returns the snapshot of the record that key is prefixed with, and the key without the prefix.
+func (s *Snapshot) namespace(key string) (*Snapshot, string, bool) {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return nil, "", false
+	}
+	ns, ok := s.namespaces[key[:i]]
+	return ns, key[i+1:], ok
+}
+
+func (s *Snapshot) throttleAllow(ctx context.Context, key string, hashKey io.Reader) bool {
+	if s.killSwitch {
+		return s.safeDefaults[key]
+	}
+	if ns, k, ok := s.namespace(key); ok {
+		return ns.throttleAllow(ctx, k, hashKey)
+	}
+	ec := EvaluationContextFromContext(ctx)
+	if o, ok := ec.Overrides[key]; ok {
+		return o
+	}
+	t := s.throttles[key]
+	if t == nil {
+		return false
+	}
+	if t.ForceRejectAll {
+		return false
+	}
+	if !t.Active(s.now()) {
+		return false
+	}
+	if !s.prerequisitesMet(ctx, t.Prerequisites) {
+		return false
+	}
+	if hashKey == nil {
+		hashKey = strings.NewReader(ec.TargetingKey)
+	}
+	if len(t.allow) > 0 || len(t.deny) > 0 || len(t.AllowSegments) > 0 || len(t.DenySegments) > 0 {
+		b, err := io.ReadAll(hashKey)
+		if err != nil {
+			return false
+		}
+		id := string(b)
+		if t.deny[id] {
+			return false
+		}
+		// A segment that cannot be loaded rejects, so that denied identifiers are never allowed.
+		denied, err := s.inSegments(ctx, t.DenySegments, id)
+		if err != nil || denied {
+			return false
+		}
+		if t.allow[id] {
+			return true
+		}
+		// An allow segment that cannot be loaded only loses its exemption, so the identifier falls through to the percentage.
+		// The error is logged by inSegments.
+		if allowed, _ := s.inSegments(ctx, t.AllowSegments, id); allowed {
+			return true
+		}
+		hashKey = bytes.NewReader(b)
+	}
+	h := s.GetHash(ctx, key, hashKey)
+	for _, wl := range t.Whitelist {
+		if h == wl {
+			return true
+		}
+	}
+	threshold := t.threshold(s.now())
+	if threshold == 0 {
+		return false
+	}
+	if threshold > t.BucketCount() {
+		return true
+	}
+	return h <= threshold
+}
+
+// inSegments returns whether id is a member of any of the named segments.
+func (s *Snapshot) inSegments(ctx context.Context, names []string, id string) (bool, error) {
+	for _, n := range names {
+		if s.segments == nil {
+			return false, errors.New("segments are not available")
+		}
+		ok, err := s.segments.contains(ctx, n, id)
+		if err != nil {
+			if s.logger != nil {
+				s.logger.Printf("flagship: failed to load segment '%s': %v", n, err)
+			}
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Snapshot) ThrottleAllow(ctx context.Context, key string, hashKey io.Reader) bool {
+	res := s.throttleAllow(ctx, key, hashKey)
+	if s.logger != nil {
+		s.logger.Printf("flagship.ThrottleAllow('%s') == '%t'", key, res)
+	}
+	return res
+}
+
+func (s *Snapshot) GetHash(ctx context.Context, key string, hashKey io.Reader) uint {
+	if ns, k, ok := s.namespace(key); ok {
+		return ns.GetHash(ctx, k, hashKey)
+	}
+	t := s.throttles[key]
+	if t == nil {
+		return GetHash(ctx, key, hashKey)
+	}
+	// Unknown algorithms are rejected when the document is fetched.
+	h, _ := GetHashWithAlgorithm(ctx, t.HashAlgorithm, t.HashSeed(key), t.Buckets, hashKey)
+	return h
+}
+
+func (s *Snapshot) Bool(ctx context.Context, key string) bool {
+	res := s.bool(ctx, key)
+	if s.logger != nil {
+		s.logger.Printf("flagship.Bool('%s') == '%t'", key, res)
+	}
+	return res
+}
+
+func (s *Snapshot) bool(ctx context.Context, key string) bool {
+	if s.killSwitch {
+		return s.safeDefaults[key]
+	}
+	if ns, k, ok := s.namespace(key); ok {
+		return ns.bool(ctx, k)
+	}
+	if o, ok := EvaluationContextFromContext(ctx).Overrides[key]; ok {
+		return o
+	}
+	return s.active(ctx, key) && s.features.Bool(key)
+}
+
+// active returns whether a feature is within its schedule and has its prerequisites met.
+func (s *Snapshot) active(ctx context.Context, key string) bool {
+	fc, ok := s.featureConfigs[key]
+	if !ok {
+		return true
+	}
+	return fc.Active(s.now()) && s.prerequisitesMet(ctx, fc.Prerequisites)
+}
+
+// prerequisitesMet returns whether every prerequisite feature has its required value.
+// Cycles are rejected when the document is loaded, so this always terminates.
+func (s *Snapshot) prerequisitesMet(ctx context.Context, ps []models.Prerequisite) bool {
+	for _, p := range ps {
+		want := p.RequiredValue()
+		if b, ok := want.(bool); ok {
+			if s.bool(ctx, p.Flag) != b {
+				return false
+			}
+			continue
+		}
+		if !s.active(ctx, p.Flag) || !reflect.DeepEqual(s.features[p.Flag], want) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Snapshot) AllBools(ctx context.Context) (allBools map[string]bool) {
+	allBools = make(map[string]bool)
+
+	for key, value := range s.features {
+		_, ok := value.(bool)
+		if ok {
+			allBools[key] = s.bool(ctx, key)
+		}
+	}
+
+	return
+}
+
+func set(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
@@ -0,0 +1,39 @@
+package flagship
+
+import (
+	"context"
+	"time"
+
+	"github.com/joerdav/flagship/internal/dynamostore"
+)
+
+// ErrNoHistory is returned by SnapshotAt for times before the audit log of a record begins.
+var ErrNoHistory = dynamostore.ErrNoHistory
+
+// TimeTraveler is implemented by feature stores that can rebuild the document as it was at a time in the past,
+// from the audit log that is written with every change.
+// The FeatureStore returned by New implements TimeTraveler.
+//
+//	snap, err := s.(flagship.TimeTraveler).SnapshotAt(ctx, time.Date(2022, 11, 22, 14, 0, 0, 0, time.UTC))
+//	if err != nil {
+//		return err
+//	}
+//	allowed := snap.ThrottleAllow(ctx, "search", strings.NewReader("user123"))
+type TimeTraveler interface {
+	SnapshotAt(ctx context.Context, at time.Time) (*Snapshot, error)
+}
+
+// SnapshotAt returns a snapshot of the document as it was at a time, which evaluates schedules and ramps at that time.
+// Segments are not versioned, so their current members are used.
+// If the audit log of a record does not go back far enough an error wrapping ErrNoHistory is returned.
+func (s *featureStore) SnapshotAt(ctx context.Context, at time.Time) (*Snapshot, error) {
+	docs, err := s.store.LoadDocumentsAt(ctx, at, s.keys()...)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDocuments(docs); err != nil {
+		return nil, err
+	}
+	now := func() time.Time { return at }
+	return newMergedSnapshot(s.records, docs, now, s.logger, s.safeDefaults, s.segmentCache.segments(s.now)), nil
+}
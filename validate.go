@@ -0,0 +1,43 @@
+package flagship
+
+import (
+	"encoding/json"
+
+	"github.com/joerdav/flagship/internal/schema"
+)
+
+// SchemaVersion is the version of the JSON Schema that documents are validated against.
+const SchemaVersion = schema.Version
+
+// ValidationError is a single problem with a document, at a path such as "throttles.search.probability".
+type ValidationError = schema.Error
+
+// InvalidDocumentError is returned by New when WithStrictValidation is used and a document is invalid.
+type InvalidDocumentError = schema.InvalidDocumentError
+
+// Schema returns the JSON Schema of the feature document.
+func Schema() []byte {
+	return append([]byte(nil), schema.JSON...)
+}
+
+// ValidateDocument returns the problems with a feature document decoded from JSON into a map.
+// A valid document returns no problems.
+//
+//	var doc map[string]interface{}
+//	err := json.Unmarshal(b, &doc)
+//	for _, e := range flagship.ValidateDocument(doc) {
+//		log.Println(e)
+//	}
+func ValidateDocument(doc map[string]interface{}) []ValidationError {
+	return schema.Validate(doc)
+}
+
+// ValidateJSON returns the problems with a feature document in JSON.
+// An error is returned if b is not a JSON object.
+func ValidateJSON(b []byte) ([]ValidationError, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return ValidateDocument(doc), nil
+}